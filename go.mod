@@ -2,4 +2,7 @@ module github.com/geistblitz/boringformat
 
 go 1.20
 
-require golang.org/x/net v0.19.0
+require (
+	golang.org/x/net v0.19.0
+	golang.org/x/text v0.14.0
+)
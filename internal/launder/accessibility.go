@@ -0,0 +1,606 @@
+package launder
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var landmarkRoles = map[string]bool{
+	"banner":        true,
+	"complementary": true,
+	"contentinfo":   true,
+	"form":          true,
+	"main":          true,
+	"navigation":    true,
+	"region":        true,
+	"search":        true,
+}
+
+var nameFromContentRoles = map[string]bool{
+	"button":           true,
+	"cell":             true,
+	"checkbox":         true,
+	"columnheader":     true,
+	"gridcell":         true,
+	"heading":          true,
+	"link":             true,
+	"menuitem":         true,
+	"menuitemcheckbox": true,
+	"menuitemradio":    true,
+	"option":           true,
+	"radio":            true,
+	"row":              true,
+	"rowheader":        true,
+	"switch":           true,
+	"tab":              true,
+	"tooltip":          true,
+	"treeitem":         true,
+	"term":             true,
+	"caption":          true,
+	"legend":           true,
+}
+
+var validRoles = map[string]bool{
+	"alert": true, "alertdialog": true, "application": true, "article": true, "banner": true,
+	"blockquote": true, "button": true, "caption": true, "cell": true, "checkbox": true,
+	"code": true, "columnheader": true, "combobox": true, "complementary": true,
+	"contentinfo": true, "definition": true, "deletion": true, "dialog": true,
+	"directory": true, "document": true, "emphasis": true, "feed": true, "figure": true,
+	"form": true, "generic": true, "grid": true, "gridcell": true, "group": true,
+	"heading": true, "img": true, "image": true, "insertion": true, "link": true,
+	"list": true, "listbox": true, "listitem": true, "log": true, "main": true,
+	"mark": true, "marquee": true, "math": true, "menu": true, "menubar": true,
+	"menuitem": true, "menuitemcheckbox": true, "menuitemradio": true, "meter": true,
+	"navigation": true, "none": true, "note": true, "option": true, "paragraph": true,
+	"presentation": true, "progressbar": true, "radio": true, "radiogroup": true,
+	"region": true, "row": true, "rowgroup": true, "rowheader": true, "scrollbar": true,
+	"search": true, "searchbox": true, "separator": true, "slider": true,
+	"spinbutton": true, "status": true, "strong": true, "subscript": true,
+	"superscript": true, "switch": true, "tab": true, "table": true, "tablist": true,
+	"tabpanel": true, "term": true, "textbox": true, "time": true, "timer": true,
+	"toolbar": true, "tooltip": true, "tree": true, "treegrid": true, "treeitem": true,
+}
+
+var implicitRoles = map[string]string{
+	"article":    "article",
+	"aside":      "complementary",
+	"nav":        "navigation",
+	"main":       "main",
+	"search":     "search",
+	"form":       "form",
+	"button":     "button",
+	"textarea":   "textbox",
+	"option":     "option",
+	"optgroup":   "group",
+	"h1":         "heading",
+	"h2":         "heading",
+	"h3":         "heading",
+	"h4":         "heading",
+	"h5":         "heading",
+	"h6":         "heading",
+	"ul":         "list",
+	"ol":         "list",
+	"menu":       "list",
+	"li":         "listitem",
+	"dt":         "term",
+	"dd":         "definition",
+	"table":      "table",
+	"thead":      "rowgroup",
+	"tbody":      "rowgroup",
+	"tfoot":      "rowgroup",
+	"tr":         "row",
+	"td":         "cell",
+	"caption":    "caption",
+	"figure":     "figure",
+	"blockquote": "blockquote",
+	"p":          "paragraph",
+	"hr":         "separator",
+	"dialog":     "dialog",
+	"details":    "group",
+	"fieldset":   "group",
+	"address":    "group",
+	"progress":   "progressbar",
+	"meter":      "meter",
+	"output":     "status",
+	"code":       "code",
+	"em":         "emphasis",
+	"strong":     "strong",
+	"del":        "deletion",
+	"ins":        "insertion",
+	"s":          "deletion",
+	"sub":        "subscript",
+	"sup":        "superscript",
+	"time":       "time",
+	"mark":       "mark",
+	"math":       "math",
+	"legend":     "legend",
+	"datalist":   "listbox",
+	"svg":        "graphics-document",
+}
+
+type AXNode struct {
+	Role     string
+	Name     string
+	Level    int
+	Node     *html.Node
+	Parent   *AXNode
+	Children []*AXNode
+}
+
+func (a *AXNode) IsLandmark() bool {
+	return landmarkRoles[a.Role]
+}
+
+func (a *AXNode) Walk(f func(*AXNode) bool) bool {
+	if !f(a) {
+		return false
+	}
+	for _, c := range a.Children {
+		if !c.Walk(f) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *AXNode) Landmark() *AXNode {
+	for p := a.Parent; p != nil; p = p.Parent {
+		if p.IsLandmark() {
+			return p
+		}
+	}
+	return nil
+}
+
+type AXTree struct {
+	Root     *AXNode
+	document *Document
+	nodes    map[*html.Node]*AXNode
+}
+
+func (d *Document) AccessibilityTree() *AXTree {
+	b := newAXBuilder(d.axIndex())
+
+	t := &AXTree{
+		Root:     &AXNode{Role: "document", Node: d.rootNode},
+		document: d,
+		nodes:    make(map[*html.Node]*AXNode),
+	}
+	t.Root.Name = strings.TrimSpace(d.Find("title").First().Text())
+	t.nodes[d.rootNode] = t.Root
+
+	for c := d.rootNode.FirstChild; c != nil; c = c.NextSibling {
+		b.build(t, t.Root, c)
+	}
+	return t
+}
+
+func (t *AXTree) Lookup(n *html.Node) *AXNode {
+	return t.nodes[n]
+}
+
+func (t *AXTree) Nearest(n *html.Node) *AXNode {
+	for ; n != nil; n = n.Parent {
+		if a := t.nodes[n]; a != nil {
+			return a
+		}
+	}
+	return nil
+}
+
+func (t *AXTree) Filter(f func(*AXNode) bool) (result []*AXNode) {
+	t.Root.Walk(func(a *AXNode) bool {
+		if f(a) {
+			result = append(result, a)
+		}
+		return true
+	})
+	return result
+}
+
+func (t *AXTree) FindRole(roles ...string) []*AXNode {
+	return t.Filter(func(a *AXNode) bool {
+		for _, r := range roles {
+			if a.Role == r {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (t *AXTree) Landmarks() []*AXNode {
+	return t.Filter((*AXNode).IsLandmark)
+}
+
+func (t *AXTree) Selection(nodes ...*AXNode) *Selection {
+	sel := newEmptySelection(t.document)
+	for _, a := range nodes {
+		sel.Nodes = append(sel.Nodes, a.Node)
+	}
+	return sel
+}
+
+func (s *Selection) Role() string {
+	if len(s.Nodes) == 0 {
+		return ""
+	}
+	return elementRole(s.Nodes[0])
+}
+
+func (s *Selection) AccessibleName() string {
+	if len(s.Nodes) == 0 || s.document == nil {
+		return ""
+	}
+	b := newAXBuilder(s.document.axIndex())
+	return b.accessibleName(s.Nodes[0], elementRole(s.Nodes[0]))
+}
+
+type axIndex struct {
+	ids    map[string]*html.Node
+	labels map[string][]*html.Node
+}
+
+func newAXIndex(root *html.Node) *axIndex {
+	x := &axIndex{
+		ids:    make(map[string]*html.Node),
+		labels: make(map[string][]*html.Node),
+	}
+	x.index(root)
+	return x
+}
+
+func (x *axIndex) index(n *html.Node) {
+	if n.Type == html.ElementNode {
+		if id, ok := getAttributeValue("id", n); ok && id != "" {
+			if _, dup := x.ids[id]; !dup {
+				x.ids[id] = n
+			}
+		}
+		if n.Data == "label" {
+			if id, ok := getAttributeValue("for", n); ok && id != "" {
+				x.labels[id] = append(x.labels[id], n)
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		x.index(c)
+	}
+}
+
+func (d *Document) axIndex() *axIndex {
+	if d.cache == nil {
+		return newAXIndex(d.rootNode)
+	}
+	d.cache.mu.Lock()
+	defer d.cache.mu.Unlock()
+	if d.cache.ax == nil {
+		d.cache.ax = newAXIndex(d.rootNode)
+	}
+	return d.cache.ax
+}
+
+type axBuilder struct {
+	*axIndex
+	vc *visibilityChecker
+}
+
+func newAXBuilder(x *axIndex) *axBuilder {
+	return &axBuilder{
+		axIndex: x,
+		vc: newVisibilityChecker(&VisibilityOptions{
+			Heuristics: HeuristicHiddenAttr | HeuristicAriaHidden | HeuristicStyle | HeuristicNonRendered,
+		}),
+	}
+}
+
+func (b *axBuilder) build(t *AXTree, parent *AXNode, n *html.Node) {
+	if n.Type != html.ElementNode || b.vc.isHiddenSelf(n) {
+		return
+	}
+
+	role := elementRole(n)
+	if role != "" && role != "generic" && role != "none" && role != "presentation" {
+		a := &AXNode{
+			Role:   role,
+			Name:   b.accessibleName(n, role),
+			Level:  headingLevel(n, role),
+			Node:   n,
+			Parent: parent,
+		}
+		parent.Children = append(parent.Children, a)
+		t.nodes[n] = a
+		parent = a
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.build(t, parent, c)
+	}
+}
+
+func elementRole(n *html.Node) string {
+	if n == nil || n.Type != html.ElementNode {
+		return ""
+	}
+
+	if r, ok := getAttributeValue("role", n); ok {
+		for _, r := range strings.Fields(strings.ToLower(r)) {
+			if validRoles[r] {
+				if r == "image" {
+					return "img"
+				}
+				return r
+			}
+		}
+	}
+
+	switch n.Data {
+	case "a", "area":
+		if _, ok := getAttributeValue("href", n); ok {
+			return "link"
+		}
+		return "generic"
+	case "header":
+		if hasSectioningAncestor(n) {
+			return "generic"
+		}
+		return "banner"
+	case "footer":
+		if hasSectioningAncestor(n) {
+			return "generic"
+		}
+		return "contentinfo"
+	case "section":
+		if hasAttributeName(n) {
+			return "region"
+		}
+		return "generic"
+	case "img":
+		if alt, ok := getAttributeValue("alt", n); ok && alt == "" {
+			return "presentation"
+		}
+		return "img"
+	case "input":
+		return inputRole(n)
+	case "select":
+		if _, ok := getAttributeValue("multiple", n); ok {
+			return "listbox"
+		}
+		if size, ok := getAttributeValue("size", n); ok {
+			if v, err := strconv.Atoi(size); err == nil && v > 1 {
+				return "listbox"
+			}
+		}
+		return "combobox"
+	case "th":
+		if scope, _ := getAttributeValue("scope", n); strings.EqualFold(scope, "row") {
+			return "rowheader"
+		}
+		return "columnheader"
+	case "body", "div", "span", "b", "i", "u", "small", "samp", "pre", "bdi", "bdo", "data", "hgroup":
+		return "generic"
+	}
+
+	return implicitRoles[n.Data]
+}
+
+func inputRole(n *html.Node) string {
+	t, _ := getAttributeValue("type", n)
+	_, hasList := getAttributeValue("list", n)
+	switch strings.ToLower(t) {
+	case "button", "submit", "reset", "image":
+		return "button"
+	case "checkbox":
+		return "checkbox"
+	case "radio":
+		return "radio"
+	case "range":
+		return "slider"
+	case "number":
+		return "spinbutton"
+	case "hidden":
+		return "none"
+	case "search":
+		if hasList {
+			return "combobox"
+		}
+		return "searchbox"
+	case "", "text", "email", "tel", "url":
+		if hasList {
+			return "combobox"
+		}
+		return "textbox"
+	}
+	return "textbox"
+}
+
+func hasSectioningAncestor(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type != html.ElementNode {
+			continue
+		}
+		switch p.Data {
+		case "article", "aside", "main", "nav", "section":
+			return true
+		}
+		switch r, _ := getAttributeValue("role", p); r {
+		case "article", "complementary", "main", "navigation", "region":
+			return true
+		}
+	}
+	return false
+}
+
+func hasAttributeName(n *html.Node) bool {
+	for _, k := range []string{"aria-label", "aria-labelledby", "title"} {
+		if v, ok := getAttributeValue(k, n); ok && strings.TrimSpace(v) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func headingLevel(n *html.Node, role string) int {
+	if role != "heading" {
+		return 0
+	}
+	if v, ok := getAttributeValue("aria-level", n); ok {
+		if l, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && l > 0 {
+			return l
+		}
+	}
+	if len(n.Data) == 2 && n.Data[0] == 'h' && '1' <= n.Data[1] && n.Data[1] <= '6' {
+		return int(n.Data[1] - '0')
+	}
+	return 2
+}
+
+func (b *axBuilder) accessibleName(n *html.Node, role string) string {
+	return normalizeSpace(b.textAlternative(n, role, make(map[*html.Node]bool), false, false))
+}
+
+func (b *axBuilder) textAlternative(n *html.Node, role string, visited map[*html.Node]bool, inLabelledBy, inContent bool) string {
+	if visited[n] {
+		return ""
+	}
+	visited[n] = true
+
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	if n.Type != html.ElementNode {
+		return ""
+	}
+	if !inLabelledBy && b.vc.isHidden(n) {
+		return ""
+	}
+
+	if !inLabelledBy {
+		if ids, ok := getAttributeValue("aria-labelledby", n); ok {
+			var parts []string
+			for _, id := range strings.Fields(ids) {
+				if ref := b.ids[id]; ref != nil {
+					parts = append(parts, b.textAlternative(ref, elementRole(ref), visited, true, false))
+				}
+			}
+			if s := strings.TrimSpace(strings.Join(parts, " ")); s != "" {
+				return s
+			}
+		}
+	}
+
+	if v, ok := getAttributeValue("aria-label", n); ok && strings.TrimSpace(v) != "" {
+		return v
+	}
+
+	if role != "presentation" && role != "none" {
+		if s := b.nativeName(n, visited); s != "" {
+			return s
+		}
+	}
+
+	if inLabelledBy || inContent || nameFromContentRoles[role] {
+		var buf strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			s := b.textAlternative(c, elementRole(c), visited, false, true)
+			if c.Type == html.ElementNode && !isInlineElement(c) {
+				s = " " + s + " "
+			}
+			buf.WriteString(s)
+		}
+		if s := buf.String(); strings.TrimSpace(s) != "" {
+			return s
+		}
+	}
+
+	if v, ok := getAttributeValue("title", n); ok {
+		return v
+	}
+	if v, ok := getAttributeValue("placeholder", n); ok {
+		return v
+	}
+	return ""
+}
+
+func (b *axBuilder) nativeName(n *html.Node, visited map[*html.Node]bool) string {
+	switch n.Data {
+	case "img", "area":
+		v, _ := getAttributeValue("alt", n)
+		return v
+	case "input":
+		t, _ := getAttributeValue("type", n)
+		switch strings.ToLower(t) {
+		case "image":
+			if v, ok := getAttributeValue("alt", n); ok {
+				return v
+			}
+			return "Submit"
+		case "submit", "reset", "button":
+			if v, ok := getAttributeValue("value", n); ok {
+				return v
+			}
+			switch strings.ToLower(t) {
+			case "submit":
+				return "Submit"
+			case "reset":
+				return "Reset"
+			}
+			return ""
+		}
+		return b.labelName(n, visited)
+	case "textarea", "select", "meter", "progress", "output":
+		return b.labelName(n, visited)
+	case "fieldset":
+		return b.childName(n, "legend", visited)
+	case "table":
+		return b.childName(n, "caption", visited)
+	case "figure":
+		return b.childName(n, "figcaption", visited)
+	case "svg":
+		return b.childName(n, "title", visited)
+	}
+	return ""
+}
+
+func (b *axBuilder) labelName(n *html.Node, visited map[*html.Node]bool) string {
+	var parts []string
+	if id, ok := getAttributeValue("id", n); ok && id != "" {
+		for _, l := range b.labels[id] {
+			parts = append(parts, b.textAlternative(l, "", visited, false, true))
+		}
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "label" {
+			parts = append(parts, b.textAlternative(p, "", visited, false, true))
+			break
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+func (b *axBuilder) childName(n *html.Node, tag string, visited map[*html.Node]bool) string {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == tag {
+			return b.textAlternative(c, "", visited, false, true)
+		}
+	}
+	return ""
+}
+
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "cite": true,
+	"code": true, "data": true, "dfn": true, "em": true, "i": true, "kbd": true,
+	"mark": true, "q": true, "s": true, "samp": true, "small": true, "span": true,
+	"strong": true, "sub": true, "sup": true, "time": true, "u": true, "var": true,
+	"img": true, "label": true,
+}
+
+func isInlineElement(n *html.Node) bool {
+	return inlineElements[n.Data]
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
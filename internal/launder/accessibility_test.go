@@ -0,0 +1,78 @@
+package launder
+
+import "testing"
+
+const accessibilityPage = `<html><head><title>AX page</title></head><body>
+<header id="top">Site</header>
+<main>
+  <article><header id="inner">Post header</header><footer id="innerfoot">Post footer</footer></article>
+  <section id="named" aria-label="Named">a</section>
+  <section id="unnamed">b</section>
+  <img id="deco" src="d.png" alt="">
+  <img id="photo" src="p.png" alt="A cat">
+  <span id="lbl1">Billing</span><span id="lbl2">address</span>
+  <input id="labelled" aria-labelledby="lbl1 lbl2">
+  <label for="email">Email</label><input id="email" type="email">
+  <button id="titled" title="Close dialog"></button>
+  <a id="link" href="/x">Read <b>more</b> <span hidden>secret</span></a>
+</main>
+<footer id="bottom">Copyright</footer>
+</body></html>`
+
+func TestImplicitRoles(t *testing.T) {
+	d := loadString(t, accessibilityPage)
+	cases := map[string]string{
+		"#top":       "banner",
+		"#inner":     "generic",
+		"#innerfoot": "generic",
+		"#bottom":    "contentinfo",
+		"#named":     "region",
+		"#unnamed":   "generic",
+		"#deco":      "presentation",
+		"#photo":     "img",
+		"#email":     "textbox",
+		"#link":      "link",
+	}
+	for sel, role := range cases {
+		if got := d.Find(sel).Role(); got != role {
+			t.Errorf("%s: expected role %q, got %q", sel, role, got)
+		}
+	}
+}
+
+func TestAccessibleName(t *testing.T) {
+	d := loadString(t, accessibilityPage)
+	cases := map[string]string{
+		"#labelled": "Billing address",
+		"#email":    "Email",
+		"#photo":    "A cat",
+		"#titled":   "Close dialog",
+		"#link":     "Read more",
+		"#named":    "Named",
+	}
+	for sel, name := range cases {
+		if got := d.Find(sel).AccessibleName(); got != name {
+			t.Errorf("%s: expected name %q, got %q", sel, name, got)
+		}
+	}
+
+	tree := d.AccessibilityTree()
+	if tree.Root.Name != "AX page" {
+		t.Errorf("unexpected document name %q", tree.Root.Name)
+	}
+	if a := tree.Lookup(d.Find("#labelled").Nodes[0]); a == nil || a.Name != "Billing address" {
+		t.Errorf("unexpected tree node %+v", a)
+	}
+	if tree.Lookup(d.Find("#deco").Nodes[0]) != nil {
+		t.Error("decorative image should not be in the tree")
+	}
+
+	d.Find("#lbl2").SetAttr("id", "other")
+	if got := d.Find("#labelled").AccessibleName(); got != "Billing" {
+		t.Errorf("expected name to follow id changes, got %q", got)
+	}
+	d.Find("main").AppendHtml(`<label for="late">Late</label><input id="late">`)
+	if got := d.Find("#late").AccessibleName(); got != "Late" {
+		t.Errorf("expected name from appended label, got %q", got)
+	}
+}
@@ -0,0 +1,538 @@
+package launder
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/geistblitz/boringformat/internal/launder/parser"
+	"golang.org/x/net/html"
+)
+
+type cssOrigin int
+
+const (
+	originUserAgent cssOrigin = iota
+	originAuthor
+)
+
+var DefaultStyleProperties = []string{
+	"display",
+	"visibility",
+	"opacity",
+	"position",
+	"left",
+	"top",
+	"right",
+	"bottom",
+	"width",
+	"height",
+	"overflow",
+	"clip",
+	"clip-path",
+	"font-size",
+	"color",
+	"text-indent",
+	"transform",
+}
+
+var inheritedProperties = map[string]bool{
+	"visibility":     true,
+	"color":          true,
+	"font-size":      true,
+	"font-family":    true,
+	"font-style":     true,
+	"font-weight":    true,
+	"line-height":    true,
+	"letter-spacing": true,
+	"text-align":     true,
+	"text-indent":    true,
+	"white-space":    true,
+	"direction":      true,
+}
+
+var initialValues = map[string]string{
+	"display":     "inline",
+	"visibility":  "visible",
+	"opacity":     "1",
+	"position":    "static",
+	"left":        "auto",
+	"top":         "auto",
+	"right":       "auto",
+	"bottom":      "auto",
+	"width":       "auto",
+	"height":      "auto",
+	"overflow":    "visible",
+	"clip":        "auto",
+	"clip-path":   "none",
+	"font-size":   "medium",
+	"color":       "canvastext",
+	"text-indent": "0",
+	"transform":   "none",
+}
+
+const userAgentStyleSheet = `
+[hidden], area, base, basefont, datalist, head, link, meta, noembed,
+noframes, param, rp, script, style, template, title { display: none }
+html, body, address, blockquote, center, dialog, div, figure, figcaption,
+footer, form, header, hr, legend, listing, main, p, plaintext, pre, xmp,
+details, summary, article, aside, h1, h2, h3, h4, h5, h6, hgroup, nav,
+section, dir, dd, dl, dt, menu, ol, ul, fieldset, optgroup { display: block }
+li { display: list-item }
+table { display: table }
+caption { display: table-caption }
+colgroup { display: table-column-group }
+col { display: table-column }
+thead { display: table-header-group }
+tbody { display: table-row-group }
+tfoot { display: table-footer-group }
+tr { display: table-row }
+td, th { display: table-cell }
+input[type=hidden i] { display: none }
+`
+
+var userAgentRules = ParseStyleSheet(userAgentStyleSheet).rules
+
+type Declaration struct {
+	Property  string
+	Value     string
+	Important bool
+}
+
+type styleRule struct {
+	selector     parser.Sel
+	specificity  parser.Specificity
+	declarations []Declaration
+}
+
+type StyleSheet struct {
+	rules []styleRule
+}
+
+func ParseStyleSheet(css string) *StyleSheet {
+	ss := &StyleSheet{}
+	ss.parse(stripCSSComments(css))
+	return ss
+}
+
+func (ss *StyleSheet) Len() int {
+	return len(ss.rules)
+}
+
+func (ss *StyleSheet) parse(css string) {
+	for i := 0; i < len(css); {
+		i = skipCSSSpace(css, i)
+		if i >= len(css) {
+			return
+		}
+
+		if strings.HasPrefix(css[i:], "<!--") {
+			i += len("<!--")
+			continue
+		}
+		if strings.HasPrefix(css[i:], "-->") {
+			i += len("-->")
+			continue
+		}
+
+		end := indexCSS(css, i, "{;}")
+		if end == -1 {
+			return
+		}
+		prelude := strings.TrimSpace(css[i:end])
+
+		if css[end] != '{' {
+			i = end + 1
+			continue
+		}
+
+		close := matchingBrace(css, end)
+		body := css[end+1 : close]
+		if close < len(css) {
+			close++
+		}
+		i = close
+
+		if strings.HasPrefix(prelude, "@") {
+			name, query := splitAtRule(prelude)
+			switch name {
+			case "media":
+				if mediaApplies(query) {
+					ss.parse(body)
+				}
+			case "supports", "layer", "document", "container":
+				ss.parse(body)
+			}
+			continue
+		}
+
+		group, err := parser.ParseGroupWithPseudoElements(prelude)
+		if err != nil {
+			continue
+		}
+		decls := ParseDeclarations(body)
+		if len(decls) == 0 {
+			continue
+		}
+		for _, sel := range group {
+			if sel.PseudoElement() != "" {
+				continue
+			}
+			ss.rules = append(ss.rules, styleRule{
+				selector:     sel,
+				specificity:  sel.Specificity(),
+				declarations: decls,
+			})
+		}
+	}
+}
+
+func ParseDeclarations(s string) []Declaration {
+	var decls []Declaration
+	for _, part := range splitCSS(stripCSSComments(s), ';') {
+		colon := strings.IndexByte(part, ':')
+		if colon == -1 {
+			continue
+		}
+		prop := strings.ToLower(strings.TrimSpace(part[:colon]))
+		val := strings.TrimSpace(part[colon+1:])
+		if prop == "" || val == "" {
+			continue
+		}
+
+		important := false
+		if bang := strings.LastIndexByte(val, '!'); bang != -1 &&
+			strings.EqualFold(strings.TrimSpace(val[bang+1:]), "important") {
+			important = true
+			val = strings.TrimSpace(val[:bang])
+		}
+		if val == "" {
+			continue
+		}
+		decls = append(decls, Declaration{Property: prop, Value: val, Important: important})
+	}
+	return decls
+}
+
+type Style map[string]string
+
+func (s Style) Get(property string) string {
+	return s[strings.ToLower(property)]
+}
+
+func (s Style) Is(property, value string) bool {
+	return strings.EqualFold(s.Get(property), value)
+}
+
+type Cascade struct {
+	sheets     []*StyleSheet
+	properties map[string]bool
+	mu         sync.Mutex
+	computed   map[*html.Node]Style
+}
+
+func NewCascade(doc *Document, properties ...string) *Cascade {
+	if len(properties) == 0 {
+		properties = DefaultStyleProperties
+	}
+
+	c := &Cascade{
+		properties: make(map[string]bool, len(properties)),
+		computed:   make(map[*html.Node]Style),
+	}
+	for _, p := range properties {
+		c.properties[strings.ToLower(p)] = true
+	}
+
+	if doc != nil {
+		doc.Find("style").Each(func(_ int, s *Selection) {
+			if media, ok := s.Attr("media"); ok && !mediaApplies(media) {
+				return
+			}
+			if t, ok := s.Attr("type"); ok && t != "" && !strings.EqualFold(t, "text/css") {
+				return
+			}
+			c.sheets = append(c.sheets, ParseStyleSheet(s.Text()))
+		})
+	}
+
+	return c
+}
+
+func (c *Cascade) AddStyleSheet(ss *StyleSheet) *Cascade {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sheets = append(c.sheets, ss)
+	c.computed = make(map[*html.Node]Style)
+	return c
+}
+
+func (c *Cascade) ComputedStyle(n *html.Node) Style {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.computedStyle(n)
+}
+
+func (c *Cascade) computedStyle(n *html.Node) Style {
+	if n == nil {
+		return c.initialStyle()
+	}
+	if st, ok := c.computed[n]; ok {
+		return st
+	}
+
+	var parentStyle Style
+	if p := n.Parent; p != nil && p.Type == html.ElementNode {
+		parentStyle = c.computedStyle(p)
+	}
+
+	if n.Type != html.ElementNode {
+		st := make(Style, len(c.properties))
+		for prop := range c.properties {
+			if parentStyle != nil {
+				st[prop] = parentStyle[prop]
+			} else {
+				st[prop] = initialValues[prop]
+			}
+		}
+		c.computed[n] = st
+		return st
+	}
+
+	specified := c.specifiedValues(n)
+	st := make(Style, len(c.properties))
+	for prop := range c.properties {
+		val, ok := specified[prop]
+		switch {
+		case !ok || strings.EqualFold(val, "unset"):
+			if inheritedProperties[prop] && parentStyle != nil {
+				val = parentStyle[prop]
+			} else {
+				val = initialValues[prop]
+			}
+		case strings.EqualFold(val, "inherit"):
+			if parentStyle != nil {
+				val = parentStyle[prop]
+			} else {
+				val = initialValues[prop]
+			}
+		case strings.EqualFold(val, "initial"):
+			val = initialValues[prop]
+		}
+		st[prop] = val
+	}
+
+	c.computed[n] = st
+	return st
+}
+
+func (c *Cascade) initialStyle() Style {
+	st := make(Style, len(c.properties))
+	for prop := range c.properties {
+		st[prop] = initialValues[prop]
+	}
+	return st
+}
+
+type cascadedDeclaration struct {
+	Declaration
+	origin      cssOrigin
+	inline      bool
+	specificity parser.Specificity
+	order       int
+}
+
+func (d cascadedDeclaration) less(o cascadedDeclaration) bool {
+	if d.Important != o.Important {
+		return o.Important
+	}
+	if d.origin != o.origin {
+		if d.Important {
+			return d.origin > o.origin
+		}
+		return d.origin < o.origin
+	}
+	if d.inline != o.inline {
+		return o.inline
+	}
+	if d.specificity != o.specificity {
+		return d.specificity.Less(o.specificity)
+	}
+	return d.order < o.order
+}
+
+func (c *Cascade) specifiedValues(n *html.Node) map[string]string {
+	var decls []cascadedDeclaration
+	order := 0
+
+	add := func(rules []styleRule, origin cssOrigin) {
+		for _, r := range rules {
+			if !r.selector.Match(n) {
+				order++
+				continue
+			}
+			for _, d := range r.declarations {
+				if c.properties[d.Property] {
+					decls = append(decls, cascadedDeclaration{d, origin, false, r.specificity, order})
+				}
+			}
+			order++
+		}
+	}
+
+	add(userAgentRules, originUserAgent)
+	for _, ss := range c.sheets {
+		add(ss.rules, originAuthor)
+	}
+	if style, ok := getAttributeValue("style", n); ok {
+		for _, d := range ParseDeclarations(style) {
+			if c.properties[d.Property] {
+				decls = append(decls, cascadedDeclaration{d, originAuthor, true, parser.Specificity{}, order})
+			}
+		}
+	}
+
+	sort.SliceStable(decls, func(i, j int) bool {
+		return decls[i].less(decls[j])
+	})
+
+	values := make(map[string]string, len(decls))
+	for _, d := range decls {
+		values[d.Property] = d.Value
+	}
+	return values
+}
+
+func mediaApplies(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	for _, q := range strings.Split(query, ",") {
+		q = strings.TrimSpace(q)
+		if strings.HasPrefix(q, "not ") {
+			continue
+		}
+		q = strings.TrimPrefix(q, "only ")
+		if !strings.HasPrefix(q, "print") && !strings.HasPrefix(q, "speech") {
+			return true
+		}
+	}
+	return false
+}
+
+func splitAtRule(prelude string) (name, rest string) {
+	prelude = prelude[1:]
+	i := strings.IndexAny(prelude, " \t\r\n\f(")
+	if i == -1 {
+		return strings.ToLower(prelude), ""
+	}
+	return strings.ToLower(prelude[:i]), strings.TrimSpace(prelude[i:])
+}
+
+func stripCSSComments(s string) string {
+	if !strings.Contains(s, "/*") {
+		return s
+	}
+
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(s) {
+				b.WriteByte(c)
+				i++
+				c = s[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			end := strings.Index(s[i+2:], "*/")
+			if end == -1 {
+				return b.String()
+			}
+			i += end + 3
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func skipCSSSpace(s string, i int) int {
+	for i < len(s) {
+		switch s[i] {
+		case ' ', '\t', '\r', '\n', '\f':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func indexCSS(s string, start int, chars string) int {
+	var quote byte
+	depth := 0
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case (c == ')' || c == ']') && depth > 0:
+			depth--
+		case depth == 0 && strings.IndexByte(chars, c) != -1:
+			return i
+		}
+	}
+	return -1
+}
+
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); {
+		next := indexCSS(s, i, "{}")
+		if next == -1 {
+			return len(s)
+		}
+		if s[next] == '{' {
+			depth++
+		} else {
+			depth--
+			if depth == 0 {
+				return next
+			}
+		}
+		i = next + 1
+	}
+	return len(s)
+}
+
+func splitCSS(s string, sep byte) []string {
+	var parts []string
+	for start := 0; start < len(s); {
+		end := indexCSS(s, start, string(sep))
+		if end == -1 {
+			parts = append(parts, s[start:])
+			break
+		}
+		parts = append(parts, s[start:end])
+		start = end + 1
+	}
+	return parts
+}
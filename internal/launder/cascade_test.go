@@ -0,0 +1,80 @@
+package launder
+
+import "testing"
+
+func TestCascadeComputedStyle(t *testing.T) {
+	doc := loadString(t, `<html><head><style>
+		/* comment { display: none } */
+		p { display: block }
+		.hidden { display: none }
+		#main p.note { visibility: hidden }
+		p.note { visibility: visible }
+		@media print { p { display: none } }
+		.force { display: none !important }
+	</style></head><body>
+		<div id="main" style="visibility: hidden">
+			<p class="note">a</p>
+			<p class="hidden">b</p>
+			<p>c</p>
+			<p style="display: inline" class="force">d</p>
+			<span style="visibility: visible"><em>e</em></span>
+		</div>
+		<div hidden>f</div>
+	</body></html>`)
+
+	c := NewCascade(doc)
+	ps := doc.Find("p")
+	cases := []struct {
+		style Style
+		prop  string
+		want  string
+	}{
+		{c.ComputedStyle(ps.Get(0)), "visibility", "hidden"},
+		{c.ComputedStyle(ps.Get(0)), "display", "block"},
+		{c.ComputedStyle(ps.Get(1)), "display", "none"},
+		{c.ComputedStyle(ps.Get(2)), "visibility", "hidden"},
+		{c.ComputedStyle(ps.Get(3)), "display", "none"},
+		{c.ComputedStyle(doc.Find("em").Get(0)), "visibility", "visible"},
+		{c.ComputedStyle(doc.Find("em").Get(0)), "display", "inline"},
+		{c.ComputedStyle(doc.Find("div[hidden]").Get(0)), "display", "none"},
+	}
+	for i, tc := range cases {
+		if got := tc.style.Get(tc.prop); got != tc.want {
+			t.Errorf("[%d] expected %s to be %q, got %q", i, tc.prop, tc.want, got)
+		}
+	}
+}
+
+func TestParseDeclarations(t *testing.T) {
+	decls := ParseDeclarations(`color: red; background: url("a;b.png"); display:none !important;;bad`)
+	if len(decls) != 3 {
+		t.Fatalf("expected 3 declarations, got %d: %+v", len(decls), decls)
+	}
+	if decls[1].Value != `url("a;b.png")` {
+		t.Errorf("unexpected value %q", decls[1].Value)
+	}
+	if !decls[2].Important || decls[2].Value != "none" {
+		t.Errorf("expected important none, got %+v", decls[2])
+	}
+}
+
+func TestCascadeImportantOrigins(t *testing.T) {
+	saved := userAgentRules
+	defer func() { userAgentRules = saved }()
+	userAgentRules = append(ParseStyleSheet(`p { visibility: hidden !important; color: gray !important; display: block }`).rules, saved...)
+
+	doc := loadString(t, `<html><head><style>
+		p { visibility: visible !important; display: inline !important }
+		p { color: red }
+	</style></head><body><p style="visibility: visible !important">x</p></body></html>`)
+	style := NewCascade(doc).ComputedStyle(doc.Find("p").Get(0))
+	if got := style.Get("visibility"); got != "hidden" {
+		t.Errorf("important user-agent rule must beat important author rules, got %q", got)
+	}
+	if got := style.Get("color"); got != "gray" {
+		t.Errorf("important user-agent rule must beat a normal author rule, got %q", got)
+	}
+	if got := style.Get("display"); got != "inline" {
+		t.Errorf("important author rule must beat a normal user-agent rule, got %q", got)
+	}
+}
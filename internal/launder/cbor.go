@@ -0,0 +1,341 @@
+package launder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	cborUint  = 0
+	cborText  = 3
+	cborArray = 4
+	cborMap   = 5
+	cborTag   = 6
+
+	maxCBORDepth = 10000
+)
+
+var (
+	errCBORTruncated = errors.New("truncated CBOR data")
+	errCBORDepth     = fmt.Errorf("CBOR data exceeds maximum nesting depth of %d", maxCBORDepth)
+)
+
+func (d *Document) MarshalCBOR() ([]byte, error) {
+	var w cborWriter
+	dd := d.dom()
+
+	n := 2
+	if dd.URL != "" {
+		n++
+	}
+	if dd.Encoding != "" {
+		n++
+	}
+	w.head(cborMap, uint64(n))
+	w.text("v")
+	w.head(cborUint, uint64(dd.Version))
+	if dd.URL != "" {
+		w.text("url")
+		w.text(dd.URL)
+	}
+	if dd.Encoding != "" {
+		w.text("enc")
+		w.text(dd.Encoding)
+	}
+	w.text("root")
+	w.node(dd.Root)
+	return w.buf, nil
+}
+
+func (d *Document) UnmarshalCBOR(data []byte) error {
+	r := &cborReader{buf: data}
+	dd, err := r.document()
+	if err != nil {
+		return err
+	}
+	if r.pos != len(data) {
+		return fmt.Errorf("%d trailing bytes after CBOR document", len(data)-r.pos)
+	}
+	return d.load(dd)
+}
+
+func NewDocumentFromCBOR(data []byte) (*Document, error) {
+	d := &Document{}
+	if err := d.UnmarshalCBOR(data); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+type cborWriter struct {
+	buf []byte
+}
+
+func (w *cborWriter) head(major byte, n uint64) {
+	m := major << 5
+	switch {
+	case n < 24:
+		w.buf = append(w.buf, m|byte(n))
+	case n <= 0xff:
+		w.buf = append(w.buf, m|24, byte(n))
+	case n <= 0xffff:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, m|25), uint16(n))
+	case n <= 0xffffffff:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, m|26), uint32(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, m|27), n)
+	}
+}
+
+func (w *cborWriter) text(s string) {
+	w.head(cborText, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *cborWriter) node(dn *domNode) {
+	n := 1
+	for _, present := range []bool{dn.Data != "", dn.Atom != "", dn.Namespace != "", len(dn.Attr) > 0, len(dn.Children) > 0} {
+		if present {
+			n++
+		}
+	}
+	w.head(cborMap, uint64(n))
+	w.text("t")
+	w.head(cborUint, uint64(dn.Type))
+	for _, f := range []struct{ key, val string }{{"d", dn.Data}, {"a", dn.Atom}, {"n", dn.Namespace}} {
+		if f.val != "" {
+			w.text(f.key)
+			w.text(f.val)
+		}
+	}
+	if len(dn.Attr) > 0 {
+		w.text("at")
+		w.head(cborArray, uint64(len(dn.Attr)))
+		for _, a := range dn.Attr {
+			w.head(cborArray, uint64(len(a)))
+			for _, s := range a {
+				w.text(s)
+			}
+		}
+	}
+	if len(dn.Children) > 0 {
+		w.text("c")
+		w.head(cborArray, uint64(len(dn.Children)))
+		for _, c := range dn.Children {
+			w.node(c)
+		}
+	}
+}
+
+type cborReader struct {
+	buf   []byte
+	pos   int
+	depth int
+}
+
+func (r *cborReader) enter() error {
+	r.depth++
+	if r.depth > maxCBORDepth {
+		return errCBORDepth
+	}
+	return nil
+}
+
+func (r *cborReader) leave() {
+	r.depth--
+}
+
+func (r *cborReader) head() (byte, uint64, error) {
+	if r.pos >= len(r.buf) {
+		return 0, 0, errCBORTruncated
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	major, info := b>>5, b&0x1f
+
+	size := 0
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info <= 27:
+		size = 1 << (info - 24)
+	default:
+		return 0, 0, fmt.Errorf("unsupported CBOR additional info %d", info)
+	}
+	if r.pos+size > len(r.buf) {
+		return 0, 0, errCBORTruncated
+	}
+	var n uint64
+	for _, c := range r.buf[r.pos : r.pos+size] {
+		n = n<<8 | uint64(c)
+	}
+	r.pos += size
+	return major, n, nil
+}
+
+func (r *cborReader) expect(major byte) (uint64, error) {
+	m, n, err := r.head()
+	if err != nil {
+		return 0, err
+	}
+	if m != major {
+		return 0, fmt.Errorf("expected CBOR major type %d, found %d", major, m)
+	}
+	if major == cborText || major == cborArray || major == cborMap {
+		if n > uint64(len(r.buf)-r.pos) {
+			return 0, errCBORTruncated
+		}
+	}
+	return n, nil
+}
+
+func (r *cborReader) text() (string, error) {
+	n, err := r.expect(cborText)
+	if err != nil {
+		return "", err
+	}
+	s := string(r.buf[r.pos : r.pos+int(n)])
+	r.pos += int(n)
+	return s, nil
+}
+
+func (r *cborReader) skip() error {
+	if err := r.enter(); err != nil {
+		return err
+	}
+	defer r.leave()
+
+	m, n, err := r.head()
+	if err != nil {
+		return err
+	}
+	switch m {
+	case 2, cborText:
+		if n > uint64(len(r.buf)-r.pos) {
+			return errCBORTruncated
+		}
+		r.pos += int(n)
+	case cborArray, cborMap:
+		if m == cborMap {
+			n *= 2
+		}
+		for i := uint64(0); i < n; i++ {
+			if err := r.skip(); err != nil {
+				return err
+			}
+		}
+	case cborTag:
+		return r.skip()
+	}
+	return nil
+}
+
+func (r *cborReader) document() (*domDocument, error) {
+	n, err := r.expect(cborMap)
+	if err != nil {
+		return nil, err
+	}
+	dd := &domDocument{}
+	for i := uint64(0); i < n; i++ {
+		key, err := r.text()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "v":
+			v, err := r.expect(cborUint)
+			if err != nil {
+				return nil, err
+			}
+			dd.Version = int(v)
+		case "url":
+			dd.URL, err = r.text()
+		case "enc":
+			dd.Encoding, err = r.text()
+		case "root":
+			dd.Root, err = r.node()
+		default:
+			err = r.skip()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dd, nil
+}
+
+func (r *cborReader) node() (*domNode, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+
+	n, err := r.expect(cborMap)
+	if err != nil {
+		return nil, err
+	}
+	dn := &domNode{}
+	for i := uint64(0); i < n; i++ {
+		key, err := r.text()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "t":
+			var t uint64
+			t, err = r.expect(cborUint)
+			dn.Type = html.NodeType(t)
+		case "d":
+			dn.Data, err = r.text()
+		case "a":
+			dn.Atom, err = r.text()
+		case "n":
+			dn.Namespace, err = r.text()
+		case "at":
+			dn.Attr, err = r.attributes()
+		case "c":
+			var count uint64
+			if count, err = r.expect(cborArray); err != nil {
+				break
+			}
+			for j := uint64(0); j < count && err == nil; j++ {
+				var c *domNode
+				c, err = r.node()
+				dn.Children = append(dn.Children, c)
+			}
+		default:
+			err = r.skip()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dn, nil
+}
+
+func (r *cborReader) attributes() ([][]string, error) {
+	n, err := r.expect(cborArray)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make([][]string, 0, n)
+	for i := uint64(0); i < n; i++ {
+		m, err := r.expect(cborArray)
+		if err != nil {
+			return nil, err
+		}
+		a := make([]string, 0, m)
+		for j := uint64(0); j < m; j++ {
+			s, err := r.text()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, s)
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs, nil
+}
@@ -0,0 +1,115 @@
+package launder
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+const (
+	charsetPrescanSize = 1024
+	charsetSniffSize   = 8192
+)
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+func DetectEncoding(content []byte, contentType string) (name string, certain bool) {
+	_, name, certain = determineEncoding(content, contentType)
+	return name, certain
+}
+
+func decodeReader(r io.Reader, contentType string) (io.Reader, string, error) {
+	br := bufio.NewReaderSize(r, charsetSniffSize)
+	peek, err := br.Peek(charsetSniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", err
+	}
+
+	enc, name, _ := determineEncoding(peek, contentType)
+	if name == "utf-8" {
+		if bytes.HasPrefix(peek, utf8BOM) {
+			br.Discard(len(utf8BOM))
+		}
+		return br, name, nil
+	}
+	return transform.NewReader(br, enc.NewDecoder()), name, nil
+}
+
+func decodeWithOffsets(raw []byte, contentType string) ([]byte, []int, string, error) {
+	peek := raw
+	if len(peek) > charsetSniffSize {
+		peek = peek[:charsetSniffSize]
+	}
+	enc, name, _ := determineEncoding(peek, contentType)
+	if name == "utf-8" {
+		if !bytes.HasPrefix(raw, utf8BOM) {
+			return raw, nil, name, nil
+		}
+		src := raw[len(utf8BOM):]
+		offsets := make([]int, len(src)+1)
+		for i := range offsets {
+			offsets[i] = i + len(utf8BOM)
+		}
+		return src, offsets, name, nil
+	}
+
+	dec := enc.NewDecoder()
+	src := make([]byte, 0, len(raw))
+	offsets := make([]int, 0, len(raw)+1)
+	var buf [32]byte
+	for p, k := 0, 1; p < len(raw); {
+		atEOF := p+k >= len(raw)
+		nDst, nSrc, err := dec.Transform(buf[:], raw[p:p+k], atEOF)
+		if err != nil && err != transform.ErrShortSrc {
+			return nil, nil, "", err
+		}
+		if nSrc == 0 && nDst == 0 {
+			if atEOF {
+				break
+			}
+			k++
+			continue
+		}
+		for i := 0; i < nDst; i++ {
+			offsets = append(offsets, p)
+		}
+		src = append(src, buf[:nDst]...)
+		p += nSrc
+		k = 1
+	}
+	offsets = append(offsets, len(raw))
+	return src, offsets, name, nil
+}
+
+func determineEncoding(content []byte, contentType string) (enc encoding.Encoding, name string, certain bool) {
+	e, name, certain := charset.DetermineEncoding(content, contentType)
+	if !certain && name == "windows-1252" && !declaresCharset(content) && validUTF8Prefix(content) {
+		e, name = charset.Lookup("utf-8")
+	}
+	return e, name, certain
+}
+
+func declaresCharset(content []byte) bool {
+	if len(content) > charsetPrescanSize {
+		content = content[:charsetPrescanSize]
+	}
+	return bytes.Contains(bytes.ToLower(content), []byte("charset"))
+}
+
+func validUTF8Prefix(content []byte) bool {
+	for i := len(content) - 1; i >= 0 && i > len(content)-utf8.UTFMax; i-- {
+		if content[i] < utf8.RuneSelf {
+			break
+		}
+		if utf8.RuneStart(content[i]) {
+			content = content[:i]
+			break
+		}
+	}
+	return utf8.Valid(content)
+}
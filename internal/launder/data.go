@@ -0,0 +1,217 @@
+package launder
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/geistblitz/boringformat/internal/launder/parser"
+	"golang.org/x/net/html"
+)
+
+func (s *Selection) SetData(key string, value interface{}) *Selection {
+	s, _ = s.writable()
+	if s.document == nil {
+		return s
+	}
+	if s.document.data == nil {
+		s.document.data = make(map[*html.Node]map[string]interface{})
+	}
+	for _, n := range s.Nodes {
+		m := s.document.data[n]
+		if m == nil {
+			m = make(map[string]interface{})
+			s.document.data[n] = m
+		}
+		m[key] = value
+	}
+	return s
+}
+
+func (s *Selection) Data(key string) (interface{}, bool) {
+	if len(s.Nodes) == 0 || s.document == nil {
+		return nil, false
+	}
+	v, ok := s.document.data[s.Nodes[0]][key]
+	return v, ok
+}
+
+func (s *Selection) HasData(key string) bool {
+	_, ok := s.Data(key)
+	return ok
+}
+
+func (s *Selection) RemoveData(key string) *Selection {
+	s, _ = s.writable()
+	if s.document == nil {
+		return s
+	}
+	for _, n := range s.Nodes {
+		if m := s.document.data[n]; m != nil {
+			delete(m, key)
+			if len(m) == 0 {
+				delete(s.document.data, n)
+			}
+		}
+	}
+	return s
+}
+
+func DataAs[T any](s *Selection, key string) (T, bool) {
+	v, ok := s.Data(key)
+	t, ok2 := v.(T)
+	return t, ok && ok2
+}
+
+func (d *Document) copyData(src, dst *html.Node) {
+	d.importData(d, src, dst)
+}
+
+func (d *Document) importData(from *Document, src, dst *html.Node) {
+	if from == nil || from.data == nil {
+		return
+	}
+	if m, ok := from.data[src]; ok {
+		if d.data == nil {
+			d.data = make(map[*html.Node]map[string]interface{})
+		}
+		cp := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			cp[k] = v
+		}
+		d.data[dst] = cp
+	}
+	for s, c := src.FirstChild, dst.FirstChild; s != nil && c != nil; s, c = s.NextSibling, c.NextSibling {
+		d.importData(from, s, c)
+	}
+}
+
+func (s *Selection) importSelection(sel *Selection) {
+	if sel.document == s.document || s.document == nil {
+		return
+	}
+	for _, n := range sel.Nodes {
+		s.document.importData(sel.document, n, n)
+	}
+}
+
+func (s *Selection) compile(selector string) Matcher {
+	if s.document == nil || !strings.Contains(selector, ":data") {
+		return compileMatcher(selector)
+	}
+	g, err := parser.ParseGroupWithPseudoClasses(selector, map[string]parser.PseudoClassFunc{
+		"data": s.document.dataPseudoClass,
+	})
+	if err != nil {
+		return invalidMatcher{}
+	}
+	return parser.Selector(g.Match)
+}
+
+type dataMatcher struct {
+	doc   *Document
+	key   string
+	op    string
+	value string
+}
+
+func (d *Document) dataPseudoClass(args string) (parser.Matcher, error) {
+	if args == "" {
+		return nil, errors.New("missing annotation key")
+	}
+	m := dataMatcher{doc: d, key: args}
+	if i := strings.IndexAny(args, "=!<>"); i >= 0 {
+		m.key = strings.TrimSpace(args[:i])
+		rest := args[i:]
+		for _, op := range []string{"!=", "<=", ">=", "=", "<", ">"} {
+			if strings.HasPrefix(rest, op) {
+				m.op = op
+				m.value = strings.TrimSpace(rest[len(op):])
+				break
+			}
+		}
+		if m.op == "" {
+			return nil, fmt.Errorf("invalid operator in %q", args)
+		}
+		if v, err := strconv.Unquote(m.value); err == nil {
+			m.value = v
+		} else if len(m.value) > 1 && m.value[0] == '\'' && m.value[len(m.value)-1] == '\'' {
+			m.value = m.value[1 : len(m.value)-1]
+		}
+	}
+	if m.key == "" {
+		return nil, fmt.Errorf("missing annotation key in %q", args)
+	}
+	return m, nil
+}
+
+func (m dataMatcher) Match(n *html.Node) bool {
+	v, ok := m.doc.data[n][m.key]
+	if !ok {
+		return false
+	}
+	if m.op == "" {
+		return true
+	}
+
+	a, aok := dataNumber(v)
+	b, err := strconv.ParseFloat(m.value, 64)
+	if aok && err == nil {
+		switch m.op {
+		case "=":
+			return a == b
+		case "!=":
+			return a != b
+		case "<":
+			return a < b
+		case "<=":
+			return a <= b
+		case ">":
+			return a > b
+		case ">=":
+			return a >= b
+		}
+	}
+
+	switch m.op {
+	case "=":
+		return fmt.Sprint(v) == m.value
+	case "!=":
+		return fmt.Sprint(v) != m.value
+	}
+	return false
+}
+
+func dataNumber(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int8:
+		return float64(x), true
+	case int16:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint:
+		return float64(x), true
+	case uint8:
+		return float64(x), true
+	case uint16:
+		return float64(x), true
+	case uint32:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float32:
+		return float64(x), true
+	case float64:
+		return x, true
+	case string:
+		f, err := strconv.ParseFloat(x, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,103 @@
+package launder
+
+import "testing"
+
+const dataPage = `<div id="main"><p id="a">one</p><p id="b">two</p><p id="c">three</p></div><div id="side"></div>`
+
+func TestData(t *testing.T) {
+	d := loadString(t, dataPage)
+	d.Find("#a").SetData("score", 0.9).SetData("label", "lead")
+	d.Find("#b").SetData("score", 0.2)
+	d.Find("#c").SetData("score", 7)
+
+	if v, ok := d.Find("#a").Data("label"); !ok || v != "lead" {
+		t.Errorf("unexpected label %v", v)
+	}
+	if f, ok := DataAs[float64](d.Find("#a"), "score"); !ok || f != 0.9 {
+		t.Errorf("unexpected typed score %v", f)
+	}
+	if _, ok := DataAs[string](d.Find("#a"), "score"); ok {
+		t.Error("expected type mismatch")
+	}
+
+	d.Find("#c").RemoveData("score")
+	if d.Find("#c").HasData("score") {
+		t.Error("expected score to be removed")
+	}
+	if len(d.Find("#a").Nodes[0].Attr) != 1 {
+		t.Error("annotations must not touch attributes")
+	}
+}
+
+func TestDataSelector(t *testing.T) {
+	d := loadString(t, dataPage)
+	d.Find("#a").SetData("score", 0.9).SetData("label", "lead")
+	d.Find("#b").SetData("score", 0.2).SetData("label", "body text")
+
+	cases := []struct {
+		sel  string
+		want string
+	}{
+		{"p:data(score)", "onetwo"},
+		{"p:data(score > 0.5)", "one"},
+		{"p:data(score<=0.2)", "two"},
+		{`p:data(label="body text")`, "two"},
+		{"p:data(label=lead)", "one"},
+		{"p:not(:data(score))", "three"},
+		{"div:has(:data(label!=lead))", "onetwothree"},
+	}
+	for _, c := range cases {
+		if got := d.Find(c.sel).Text(); got != c.want {
+			t.Errorf("%s: expected %q, got %q", c.sel, c.want, got)
+		}
+	}
+	if d.Find("#a").Is(":data(label=lead)") != true {
+		t.Error("Is should support :data")
+	}
+	if d.Find("p:data()").Length() != 0 {
+		t.Error("expected invalid selector to match nothing")
+	}
+}
+
+func TestDataSurvivesManipulation(t *testing.T) {
+	d := loadString(t, dataPage)
+	d.Find("#a").SetData("label", "lead")
+
+	clone := d.Find("#a").Clone()
+	if v, _ := clone.Data("label"); v != "lead" {
+		t.Errorf("Clone lost annotation: %v", v)
+	}
+
+	d.Find("#a").WrapHtml("<section></section>")
+	if v, _ := d.Find("section > #a").Data("label"); v != "lead" {
+		t.Errorf("Wrap lost annotation: %v", v)
+	}
+
+	d.Find("#side").SetData("label", "wrapper")
+	d.Find("#b").WrapSelection(d.Find("#side"))
+	if n := d.Find("#main div:data(label=wrapper) > #b").Length(); n != 1 {
+		t.Error("wrapper clone lost annotation")
+	}
+
+	d.Find("#c").ReplaceWithSelection(d.Find("#a"))
+	if v, _ := d.Find("#a").Data("label"); v != "lead" {
+		t.Errorf("ReplaceWith lost annotation: %v", v)
+	}
+
+	d.Find("#a, #b").AppendSelection(d.Find("section").SetData("label", "tail"))
+	if n := d.Find("section:data(label=tail)").Length(); n != 2 {
+		t.Errorf("expected both appended copies annotated, found %d", n)
+	}
+
+	other := loadString(t, `<ul><li>x</li></ul>`)
+	other.Find("li").SetData("label", "imported")
+	d.Find("#a").AppendSelection(other.Find("li"))
+	if n := d.Find("li:data(label=imported)").Length(); n != 1 {
+		t.Errorf("expected annotation imported from other document, found %d", n)
+	}
+
+	cd := CloneDocument(d)
+	if n := cd.Find(":data(label)").Length(); n != d.Find(":data(label)").Length() || n == 0 {
+		t.Errorf("CloneDocument lost annotations: %d", n)
+	}
+}
@@ -0,0 +1,399 @@
+package launder
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/html"
+)
+
+type EditOp int
+
+const (
+	OpInsert EditOp = iota
+	OpDelete
+	OpMove
+	OpText
+	OpAttr
+)
+
+var editOpNames = []string{
+	OpInsert: "insert",
+	OpDelete: "delete",
+	OpMove:   "move",
+	OpText:   "text",
+	OpAttr:   "attr",
+}
+
+func (op EditOp) String() string {
+	if op >= 0 && int(op) < len(editOpNames) {
+		return editOpNames[op]
+	}
+	return "unknown"
+}
+
+type Edit struct {
+	Op       EditOp
+	Path     []int
+	To       int
+	Selector string
+	Node     *html.Node
+	Text     string
+	Attr     html.Attribute
+	Removed  bool
+	OldNode  *html.Node
+	NewNode  *html.Node
+}
+
+func (e Edit) String() string {
+	switch e.Op {
+	case OpMove:
+		return fmt.Sprintf("%s %v -> %d (%s)", e.Op, e.Path, e.To, e.Selector)
+	case OpText:
+		return fmt.Sprintf("%s %v %q (%s)", e.Op, e.Path, e.Text, e.Selector)
+	case OpAttr:
+		if e.Removed {
+			return fmt.Sprintf("%s %v -%s (%s)", e.Op, e.Path, e.Attr.Key, e.Selector)
+		}
+		return fmt.Sprintf("%s %v %s=%q (%s)", e.Op, e.Path, e.Attr.Key, e.Attr.Val, e.Selector)
+	default:
+		return fmt.Sprintf("%s %v (%s)", e.Op, e.Path, e.Selector)
+	}
+}
+
+type Patch struct {
+	Edits []Edit
+}
+
+var ErrInvalidPath = errors.New("edit path does not resolve to a node")
+
+func Diff(a, b *Document) *Patch {
+	return DiffWith(a, b, NewSubtreeHasher(&HashOptions{}))
+}
+
+func DiffWith(a, b *Document, h *SubtreeHasher) *Patch {
+	h.sync(a)
+	h.sync(b)
+	d := &differ{hasher: h}
+	d.diffChildren(a.rootNode, b.rootNode, nil)
+	return &Patch{Edits: d.edits}
+}
+
+func (p *Patch) Empty() bool {
+	return len(p.Edits) == 0
+}
+
+func (p *Patch) Apply(doc *Document) error {
+	if doc.readOnly {
+		return ErrReadOnly
+	}
+	defer doc.touch()
+	for i, e := range p.Edits {
+		if err := applyEdit(doc.rootNode, e); err != nil {
+			if doc.HasPositions() {
+				return fmt.Errorf("edit %d (%s) at %s: %w", i, e.Op, doc.describe(deepestNode(doc.rootNode, e.Path)), err)
+			}
+			return fmt.Errorf("edit %d (%s): %w", i, e.Op, err)
+		}
+	}
+	return nil
+}
+
+func (p *Patch) ChangedNodes() []*html.Node {
+	var result []*html.Node
+	seen := make(map[*html.Node]bool)
+	for _, e := range p.Edits {
+		n := e.NewNode
+		if n == nil {
+			n = e.OldNode
+		}
+		for n != nil && n.Type != html.ElementNode {
+			n = n.Parent
+		}
+		if n != nil && !seen[n] {
+			seen[n] = true
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+func applyEdit(root *html.Node, e Edit) error {
+	switch e.Op {
+	case OpInsert:
+		if len(e.Path) == 0 || e.Node == nil {
+			return ErrInvalidPath
+		}
+		parent := resolvePath(root, e.Path[:len(e.Path)-1])
+		if parent == nil {
+			return ErrInvalidPath
+		}
+		idx := e.Path[len(e.Path)-1]
+		ref := nthChild(parent, idx)
+		if ref == nil && idx != countChildren(parent) {
+			return ErrInvalidPath
+		}
+		parent.InsertBefore(cloneNode(e.Node), ref)
+
+	case OpDelete:
+		n := resolvePath(root, e.Path)
+		if n == nil || n.Parent == nil {
+			return ErrInvalidPath
+		}
+		n.Parent.RemoveChild(n)
+
+	case OpMove:
+		n := resolvePath(root, e.Path)
+		if n == nil || n.Parent == nil {
+			return ErrInvalidPath
+		}
+		parent := n.Parent
+		parent.RemoveChild(n)
+		ref := nthChild(parent, e.To)
+		if ref == nil && e.To != countChildren(parent) {
+			return ErrInvalidPath
+		}
+		parent.InsertBefore(n, ref)
+
+	case OpText:
+		n := resolvePath(root, e.Path)
+		if n == nil {
+			return ErrInvalidPath
+		}
+		n.Data = e.Text
+
+	case OpAttr:
+		n := resolvePath(root, e.Path)
+		if n == nil || n.Type != html.ElementNode {
+			return ErrInvalidPath
+		}
+		if e.Removed {
+			removeAttr(n, e.Attr.Key)
+		} else if a := getAttributePtr(e.Attr.Key, n); a != nil {
+			a.Val = e.Attr.Val
+		} else {
+			n.Attr = append(n.Attr, e.Attr)
+		}
+
+	default:
+		return fmt.Errorf("unknown edit operation %d", e.Op)
+	}
+	return nil
+}
+
+func resolvePath(root *html.Node, path []int) *html.Node {
+	n := root
+	for _, i := range path {
+		if n = nthChild(n, i); n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+func deepestNode(root *html.Node, path []int) *html.Node {
+	n := root
+	for _, i := range path {
+		c := nthChild(n, i)
+		if c == nil {
+			break
+		}
+		n = c
+	}
+	return n
+}
+
+func countChildren(n *html.Node) int {
+	i := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		i++
+	}
+	return i
+}
+
+type differ struct {
+	edits  []Edit
+	hasher *SubtreeHasher
+}
+
+func (d *differ) hash(n *html.Node) uint64 {
+	return d.hasher.Hash(n)
+}
+
+func diffKey(n *html.Node) string {
+	id, _ := getAttributeValue("id", n)
+	return fmt.Sprintf("%d\x00%s\x00%s\x00%s", n.Type, n.Namespace, dataKey(n), id)
+}
+
+func dataKey(n *html.Node) string {
+	if n.Type == html.ElementNode || n.Type == html.DoctypeNode {
+		return n.Data
+	}
+	return ""
+}
+
+func (d *differ) diffNode(a, b *html.Node, path []int) {
+	switch a.Type {
+	case html.TextNode, html.CommentNode, html.DoctypeNode:
+		if a.Data != b.Data {
+			d.edits = append(d.edits, Edit{
+				Op: OpText, Path: copyPath(path), Selector: NodePath(b), Text: b.Data, OldNode: a, NewNode: b,
+			})
+		}
+		return
+	case html.ElementNode:
+		d.diffAttrs(a, b, path)
+	}
+
+	if d.hash(a) != d.hash(b) {
+		d.diffChildren(a, b, path)
+	}
+}
+
+func (d *differ) diffAttrs(a, b *html.Node, path []int) {
+	for _, attr := range a.Attr {
+		if _, ok := getAttributeValue(attr.Key, b); !ok {
+			d.edits = append(d.edits, Edit{
+				Op: OpAttr, Path: copyPath(path), Selector: NodePath(b), Attr: attr, Removed: true, OldNode: a, NewNode: b,
+			})
+		}
+	}
+	for _, attr := range b.Attr {
+		if v, ok := getAttributeValue(attr.Key, a); !ok || v != attr.Val {
+			d.edits = append(d.edits, Edit{
+				Op: OpAttr, Path: copyPath(path), Selector: NodePath(b), Attr: attr, OldNode: a, NewNode: b,
+			})
+		}
+	}
+}
+
+func (d *differ) diffChildren(a, b *html.Node, path []int) {
+	var olds, news []*html.Node
+	for c := a.FirstChild; c != nil; c = c.NextSibling {
+		olds = append(olds, c)
+	}
+	for c := b.FirstChild; c != nil; c = c.NextSibling {
+		news = append(news, c)
+	}
+
+	match := make([]int, len(news))
+	for i := range match {
+		match[i] = -1
+	}
+	used := make([]bool, len(olds))
+
+	d.lcsMatch(olds, news, used, match, func(o, n *html.Node) bool { return d.hash(o) == d.hash(n) })
+	d.lcsMatch(olds, news, used, match, func(o, n *html.Node) bool { return diffKey(o) == diffKey(n) })
+	for j, n := range news {
+		if match[j] != -1 {
+			continue
+		}
+		for i, o := range olds {
+			if !used[i] && d.hash(o) == d.hash(n) {
+				match[j], used[i] = i, true
+				break
+			}
+		}
+	}
+
+	current := make([]int, len(olds))
+	for i := range current {
+		current[i] = i
+	}
+	for i := len(olds) - 1; i >= 0; i-- {
+		if used[i] {
+			continue
+		}
+		d.edits = append(d.edits, Edit{
+			Op: OpDelete, Path: appendPath(path, i), Selector: NodePath(olds[i]), OldNode: olds[i],
+		})
+		current = append(current[:i], current[i+1:]...)
+	}
+
+	for j, n := range news {
+		if match[j] == -1 {
+			d.edits = append(d.edits, Edit{
+				Op: OpInsert, Path: appendPath(path, j), Selector: NodePath(n), Node: cloneNode(n), NewNode: n,
+			})
+			current = append(current[:j], append([]int{-1}, current[j:]...)...)
+			continue
+		}
+
+		k := j
+		for k < len(current) && current[k] != match[j] {
+			k++
+		}
+		if k != j {
+			d.edits = append(d.edits, Edit{
+				Op: OpMove, Path: appendPath(path, k), To: j, Selector: NodePath(n), OldNode: olds[match[j]], NewNode: n,
+			})
+			moved := current[k]
+			current = append(current[:k], current[k+1:]...)
+			current = append(current[:j], append([]int{moved}, current[j:]...)...)
+		}
+	}
+
+	for j, n := range news {
+		if i := match[j]; i != -1 {
+			d.diffNode(olds[i], n, appendPath(path, j))
+		}
+	}
+}
+
+func (d *differ) lcsMatch(olds, news []*html.Node, used []bool, match []int, eq func(o, n *html.Node) bool) {
+	var oi, ni []int
+	for i := range olds {
+		if !used[i] {
+			oi = append(oi, i)
+		}
+	}
+	for j := range news {
+		if match[j] == -1 {
+			ni = append(ni, j)
+		}
+	}
+	if len(oi) == 0 || len(ni) == 0 {
+		return
+	}
+
+	table := make([][]int, len(oi)+1)
+	for i := range table {
+		table[i] = make([]int, len(ni)+1)
+	}
+	for i := len(oi) - 1; i >= 0; i-- {
+		for j := len(ni) - 1; j >= 0; j-- {
+			if eq(olds[oi[i]], news[ni[j]]) {
+				table[i][j] = table[i+1][j+1] + 1
+			} else if table[i+1][j] >= table[i][j+1] {
+				table[i][j] = table[i+1][j]
+			} else {
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+
+	for i, j := 0, 0; i < len(oi) && j < len(ni); {
+		switch {
+		case eq(olds[oi[i]], news[ni[j]]):
+			match[ni[j]] = oi[i]
+			used[oi[i]] = true
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+}
+
+func appendPath(path []int, i int) []int {
+	p := make([]int, len(path)+1)
+	copy(p, path)
+	p[len(path)] = i
+	return p
+}
+
+func copyPath(path []int) []int {
+	return append([]int(nil), path...)
+}
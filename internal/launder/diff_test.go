@@ -0,0 +1,46 @@
+package launder
+
+import "testing"
+
+func TestDiffApply(t *testing.T) {
+	cases := []struct {
+		a, b string
+	}{
+		{`<p>a</p><p>b</p>`, `<p>a</p><p>b</p>`},
+		{`<p>a</p><p>b</p>`, `<p>b</p><p>a</p>`},
+		{`<ul><li>1</li><li>2</li><li>3</li></ul>`, `<ul><li>3</li><li>1</li><li>4</li></ul>`},
+		{`<div id="x" class="a">text</div>`, `<div id="x" title="t">changed</div>`},
+		{`<div><span>a</span>tail<!-- c --></div>`, `<section><em>new</em></section><div>tail<!-- d --><span>a</span></div>`},
+	}
+
+	for _, c := range cases {
+		a, b := loadString(t, c.a), loadString(t, c.b)
+		p := Diff(a, b)
+		if c.a == c.b && !p.Empty() {
+			t.Errorf("%s: expected empty patch, got %v", c.a, p.Edits)
+		}
+		if err := p.Apply(a); err != nil {
+			t.Fatalf("%s: %v", c.a, err)
+		}
+		got, _ := OuterHtml(a.Selection)
+		want, _ := OuterHtml(b.Selection)
+		if got != want {
+			t.Errorf("%s -> %s: got %s", c.a, c.b, got)
+		}
+		if !Diff(a, b).Empty() {
+			t.Errorf("%s: patched document still differs", c.a)
+		}
+	}
+}
+
+func TestDiffMove(t *testing.T) {
+	a := loadString(t, `<ul><li>1</li><li>2</li><li>3</li></ul>`)
+	b := loadString(t, `<ul><li>3</li><li>1</li><li>2</li></ul>`)
+	p := Diff(a, b)
+	if len(p.Edits) != 1 || p.Edits[0].Op != OpMove {
+		t.Fatalf("expected a single move, got %v", p.Edits)
+	}
+	if n := p.ChangedNodes(); len(n) != 1 || n[0].Data != "li" {
+		t.Errorf("expected the moved li as changed node, got %v", n)
+	}
+}
@@ -1 +1,23 @@
+// Package launder queries, cleans and serializes HTML documents.
+//
+// A Document is not safe for concurrent use while it is being modified.
+// Document.Snapshot returns a deep copy that is read-only and may be shared
+// between goroutines without locking. On a snapshot the following are safe
+// for concurrent use: selection and traversal (Find, Filter, Is, Children,
+// Parent, Closest, Each, Map, ...), property reads (Attr, Text, Html,
+// OuterHtml, HtmlAll, HasClass, Data, HasData, Position), rendering
+// (Render, RenderWithOptions, RenderSelection), visibility, accessibility,
+// cascade and injection queries, fingerprinting, locators, diffing and the
+// JSON and CBOR encoders.
+//
+// Mutating Selection methods never modify a snapshot. Each mutation called
+// on a snapshot's selection copies the snapshot into a new writable document
+// and returns a selection in that copy, so later calls chained on the result
+// edit the same copy and goroutines mutating through one snapshot never share
+// nodes. Selection.Document returns the copy; Document.WorkingCopy returns a
+// new one to edit in several steps. Selection arguments taken from the same
+// snapshot refer to the matching nodes of the copy. Clone on a snapshot
+// returns detached nodes owned by a fresh document. Patch.Apply and
+// DetectInjections with Strip set return ErrReadOnly instead. Raw *html.Node
+// values taken from a snapshot must not be modified directly.
 package launder
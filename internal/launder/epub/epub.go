@@ -0,0 +1,409 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/geistblitz/boringformat/internal/launder"
+	"github.com/geistblitz/boringformat/internal/launder/internal/textutil"
+	"github.com/geistblitz/boringformat/internal/launder/parser"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	ErrNoRootFile = errors.New("epub: container has no rootfile")
+	ErrNoChapter  = errors.New("epub: no chapter to resolve from")
+)
+
+type Metadata struct {
+	Title      string
+	Authors    []string
+	Language   string
+	Identifier string
+	Publisher  string
+	Date       string
+}
+
+type Item struct {
+	ID         string
+	Path       string
+	MediaType  string
+	Properties []string
+}
+
+type Chapter struct {
+	Item
+	Linear   bool
+	Document *launder.Document
+}
+
+type TOCEntry struct {
+	Title    string
+	Path     string
+	Fragment string
+	Children []*TOCEntry
+	Parent   *TOCEntry
+}
+
+type Book struct {
+	Metadata Metadata
+	Items    map[string]*Item
+	Chapters []*Chapter
+	TOC      []*TOCEntry
+
+	chapters map[string]*Chapter
+	files    map[string]*zip.File
+	closer   io.Closer
+}
+
+func Open(name string) (*Book, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := newBook(&zr.Reader)
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	b.closer = zr
+	return b, nil
+}
+
+func NewReader(r io.ReaderAt, size int64) (*Book, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return newBook(zr)
+}
+
+func (b *Book) Close() error {
+	if b.closer != nil {
+		return b.closer.Close()
+	}
+	return nil
+}
+
+func (b *Book) Open(name string) (io.ReadCloser, error) {
+	f, ok := b.files[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return nil, fmt.Errorf("epub: %s not found", name)
+	}
+	return f.Open()
+}
+
+func (b *Book) Chapter(name string) *Chapter {
+	return b.chapters[strings.TrimPrefix(name, "/")]
+}
+
+func (b *Book) Resolve(from *Chapter, href string) (*Chapter, *launder.Selection, error) {
+	if from == nil {
+		return nil, nil, ErrNoChapter
+	}
+	p, frag := b.resolveHref(from.Path, href)
+	ch := b.Chapter(p)
+	if ch == nil {
+		return nil, nil, nil
+	}
+	if frag == "" {
+		return ch, ch.Document.Find("body"), nil
+	}
+	return ch, anchor(ch.Document, frag), nil
+}
+
+func (b *Book) ResolveEntry(e *TOCEntry) (*Chapter, *launder.Selection) {
+	ch := b.Chapter(e.Path)
+	if ch == nil {
+		return nil, nil
+	}
+	if e.Fragment == "" {
+		return ch, ch.Document.Find("body")
+	}
+	return ch, anchor(ch.Document, e.Fragment)
+}
+
+func (b *Book) Breadcrumbs(ch *Chapter) []*TOCEntry {
+	var best []*TOCEntry
+	var walk func(entries []*TOCEntry, trail []*TOCEntry)
+	walk = func(entries []*TOCEntry, trail []*TOCEntry) {
+		for _, e := range entries {
+			t := append(trail[:len(trail):len(trail)], e)
+			if e.Path == ch.Path && len(t) > len(best) {
+				best = t
+			}
+			walk(e.Children, t)
+		}
+	}
+	walk(b.TOC, nil)
+	return best
+}
+
+func anchor(doc *launder.Document, frag string) *launder.Selection {
+	q := parser.QuoteString(frag)
+	return doc.Find("[id=" + q + "], a[name=" + q + "]").First()
+}
+
+type container struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type opfPackage struct {
+	Metadata struct {
+		Titles      []string `xml:"title"`
+		Creators    []string `xml:"creator"`
+		Languages   []string `xml:"language"`
+		Identifiers []string `xml:"identifier"`
+		Publishers  []string `xml:"publisher"`
+		Dates       []string `xml:"date"`
+	} `xml:"metadata"`
+	Manifest []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine struct {
+		Toc      string `xml:"toc,attr"`
+		Itemrefs []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+type ncxPoint struct {
+	Label  string     `xml:"navLabel>text"`
+	Src    ncxContent `xml:"content"`
+	Points []ncxPoint `xml:"navPoint"`
+}
+
+type ncxContent struct {
+	Src string `xml:"src,attr"`
+}
+
+type ncx struct {
+	Points []ncxPoint `xml:"navMap>navPoint"`
+}
+
+func newBook(zr *zip.Reader) (*Book, error) {
+	b := &Book{
+		Items:    make(map[string]*Item),
+		chapters: make(map[string]*Chapter),
+		files:    make(map[string]*zip.File),
+	}
+	for _, f := range zr.File {
+		b.files[f.Name] = f
+	}
+
+	var c container
+	if err := b.decodeXML("META-INF/container.xml", &c); err != nil {
+		return nil, err
+	}
+	rootfile := ""
+	for _, rf := range c.Rootfiles {
+		if rf.MediaType == "" || rf.MediaType == "application/oebps-package+xml" {
+			rootfile = rf.FullPath
+			break
+		}
+	}
+	if rootfile == "" {
+		return nil, ErrNoRootFile
+	}
+
+	var pkg opfPackage
+	if err := b.decodeXML(rootfile, &pkg); err != nil {
+		return nil, err
+	}
+	b.Metadata = Metadata{
+		Title:      first(pkg.Metadata.Titles),
+		Authors:    textutil.TrimAll(pkg.Metadata.Creators),
+		Language:   first(pkg.Metadata.Languages),
+		Identifier: first(pkg.Metadata.Identifiers),
+		Publisher:  first(pkg.Metadata.Publishers),
+		Date:       first(pkg.Metadata.Dates),
+	}
+
+	byID := make(map[string]*Item)
+	var nav, ncxItem *Item
+	for _, it := range pkg.Manifest {
+		p, _ := b.resolveHref(rootfile, it.Href)
+		item := &Item{ID: it.ID, Path: p, MediaType: it.MediaType, Properties: strings.Fields(it.Properties)}
+		byID[it.ID] = item
+		b.Items[p] = item
+		for _, prop := range item.Properties {
+			if prop == "nav" {
+				nav = item
+			}
+		}
+		if it.MediaType == "application/x-dtbncx+xml" && (pkg.Spine.Toc == "" || pkg.Spine.Toc == it.ID) {
+			ncxItem = item
+		}
+	}
+
+	for _, ref := range pkg.Spine.Itemrefs {
+		item, ok := byID[ref.IDRef]
+		if !ok {
+			continue
+		}
+		doc, err := b.load(item)
+		if err != nil {
+			return nil, err
+		}
+		ch := &Chapter{Item: *item, Linear: ref.Linear != "no", Document: doc}
+		b.Chapters = append(b.Chapters, ch)
+		b.chapters[item.Path] = ch
+	}
+
+	switch {
+	case nav != nil:
+		doc, err := b.load(nav)
+		if err != nil {
+			return nil, err
+		}
+		b.TOC = b.navTOC(nav.Path, doc)
+	case ncxItem != nil:
+		var n ncx
+		if err := b.decodeXML(ncxItem.Path, &n); err != nil {
+			return nil, err
+		}
+		b.TOC = b.ncxTOC(ncxItem.Path, n.Points, nil)
+	}
+
+	return b, nil
+}
+
+func (b *Book) load(item *Item) (*launder.Document, error) {
+	rc, err := b.Open(item.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var r io.Reader = rc
+	if item.MediaType == "" || item.MediaType == "application/xhtml+xml" {
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(expandSelfClosing(data))
+	}
+	doc, err := launder.NewDocumentFromReaderWithContentType(r, "application/xhtml+xml")
+	if err != nil {
+		return nil, err
+	}
+	doc.Url = &url.URL{Scheme: "epub", Path: "/" + item.Path}
+	return doc, nil
+}
+
+var voidElements = map[atom.Atom]bool{
+	atom.Area: true, atom.Base: true, atom.Br: true, atom.Col: true, atom.Embed: true,
+	atom.Hr: true, atom.Img: true, atom.Input: true, atom.Keygen: true, atom.Link: true,
+	atom.Meta: true, atom.Param: true, atom.Source: true, atom.Track: true, atom.Wbr: true,
+}
+
+func expandSelfClosing(data []byte) []byte {
+	var buf bytes.Buffer
+	z := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return buf.Bytes()
+		}
+		raw := z.Raw()
+		if tt == html.SelfClosingTagToken {
+			name, _ := z.TagName()
+			if !voidElements[atom.Lookup(name)] {
+				z.NextIsNotRawText()
+				buf.Write(raw[:len(raw)-2])
+				buf.WriteString("></" + string(name) + ">")
+				continue
+			}
+		}
+		buf.Write(raw)
+	}
+}
+
+func (b *Book) decodeXML(name string, v interface{}) error {
+	rc, err := b.Open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("epub: %s: %w", name, err)
+	}
+	return nil
+}
+
+func (b *Book) resolveHref(base, href string) (string, string) {
+	frag := ""
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		href, frag = href[:i], href[i+1:]
+	}
+	if u, err := url.PathUnescape(href); err == nil {
+		href = u
+	}
+	if href == "" {
+		return base, frag
+	}
+	if strings.HasPrefix(href, "/") {
+		return strings.TrimPrefix(path.Clean(href), "/"), frag
+	}
+	return path.Join(path.Dir(base), href), frag
+}
+
+func (b *Book) navTOC(base string, doc *launder.Document) []*TOCEntry {
+	navs := doc.Find("nav")
+	toc := navs.FilterFunction(func(i int, s *launder.Selection) bool {
+		t, _ := s.Attr("epub:type")
+		return strings.Contains(" "+t+" ", " toc ")
+	})
+	if toc.Length() == 0 {
+		toc = navs
+	}
+	return b.navList(base, toc.First().ChildrenFiltered("ol"), nil)
+}
+
+func (b *Book) navList(base string, ol *launder.Selection, parent *TOCEntry) []*TOCEntry {
+	var entries []*TOCEntry
+	ol.ChildrenFiltered("li").Each(func(i int, li *launder.Selection) {
+		label := li.ChildrenFiltered("a, span").First()
+		e := &TOCEntry{Title: strings.Join(strings.Fields(label.Text()), " "), Parent: parent}
+		if href, ok := label.Attr("href"); ok {
+			e.Path, e.Fragment = b.resolveHref(base, href)
+		}
+		e.Children = b.navList(base, li.ChildrenFiltered("ol"), e)
+		entries = append(entries, e)
+	})
+	return entries
+}
+
+func (b *Book) ncxTOC(base string, points []ncxPoint, parent *TOCEntry) []*TOCEntry {
+	var entries []*TOCEntry
+	for _, p := range points {
+		e := &TOCEntry{Title: strings.Join(strings.Fields(p.Label), " "), Parent: parent}
+		e.Path, e.Fragment = b.resolveHref(base, p.Src.Src)
+		e.Children = b.ncxTOC(base, p.Points, e)
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[0])
+}
@@ -0,0 +1,130 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testContainer = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`
+
+const testOPF = `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Go in Practice</dc:title>
+    <dc:creator>Ada</dc:creator>
+    <dc:creator>Grace</dc:creator>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="c2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine toc="ncx"><itemref idref="c1"/><itemref idref="c2"/></spine>
+</package>`
+
+const testNav = `<html xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="landmarks"><ol><li><a href="text/ch2.xhtml">Wrong</a></li></ol></nav>
+<nav epub:type="toc"><ol>
+  <li><a href="text/ch1.xhtml">Basics</a></li>
+  <li><a href="text/ch2.xhtml">Concurrency</a><ol><li><a href="text/ch2.xhtml#chan">Channels</a></li></ol></li>
+</ol></nav></body></html>`
+
+const testNCX = `<?xml version="1.0"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/"><navMap>
+  <navPoint><navLabel><text>Basics</text></navLabel><content src="text/ch1.xhtml"/></navPoint>
+  <navPoint><navLabel><text>Concurrency</text></navLabel><content src="text/ch2.xhtml"/>
+    <navPoint><navLabel><text>Channels</text></navLabel><content src="text/ch2.xhtml#chan"/></navPoint>
+  </navPoint>
+</navMap></ncx>`
+
+func testBook(t *testing.T, withNav bool) *Book {
+	files := map[string]string{
+		"mimetype":               "application/epub+zip",
+		"META-INF/container.xml": testContainer,
+		"OEBPS/content.opf":      testOPF,
+		"OEBPS/toc.ncx":          testNCX,
+		"OEBPS/text/ch1.xhtml":   `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Basics</title><script src="a.js"/><link rel="stylesheet" href="s.css"/></head><body><h1>Basics</h1><div class="spacer"/><a id="top"/><p>Intro<br/>text</p><a href="ch2.xhtml#chan">see channels</a></body></html>`,
+		"OEBPS/text/ch2.xhtml":   `<html><body><h1>Concurrency</h1><h2 id="chan">Channels</h2></body></html>`,
+	}
+	if withNav {
+		files["OEBPS/nav.xhtml"] = testNav
+	} else {
+		files["OEBPS/content.opf"] = strings.Replace(testOPF, `<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>`, "", 1)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	b, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestBook(t *testing.T) {
+	for _, withNav := range []bool{true, false} {
+		b := testBook(t, withNav)
+
+		if b.Metadata.Title != "Go in Practice" || len(b.Metadata.Authors) != 2 || b.Metadata.Language != "en" {
+			t.Errorf("unexpected metadata %+v", b.Metadata)
+		}
+
+		if len(b.Chapters) != 2 || b.Chapters[0].Path != "OEBPS/text/ch1.xhtml" {
+			t.Fatalf("unexpected spine %+v", b.Chapters)
+		}
+		if h := b.Chapters[1].Document.Find("h1").Text(); h != "Concurrency" {
+			t.Errorf("expected second chapter in reading order, got %q", h)
+		}
+
+		if len(b.TOC) != 2 || b.TOC[1].Title != "Concurrency" || len(b.TOC[1].Children) != 1 {
+			t.Fatalf("nav=%v: unexpected TOC %+v", withNav, b.TOC)
+		}
+
+		href, _ := b.Chapters[0].Document.Find("a[href]").Attr("href")
+		ch, target, err := b.Resolve(b.Chapters[0], href)
+		if err != nil || ch != b.Chapters[1] || target.Text() != "Channels" {
+			t.Errorf("nav=%v: link %q resolved to %v %q", withNav, href, ch, target.Text())
+		}
+
+		crumbs := b.Breadcrumbs(b.Chapters[1])
+		if len(crumbs) != 2 || crumbs[0].Title != "Concurrency" || crumbs[1].Title != "Channels" {
+			t.Errorf("nav=%v: unexpected breadcrumbs %v", withNav, crumbs)
+		}
+	}
+}
+
+func TestResolveWithoutChapter(t *testing.T) {
+	b := testBook(t, true)
+	if _, _, err := b.Resolve(nil, "text/ch1.xhtml"); err != ErrNoChapter {
+		t.Errorf("expected ErrNoChapter, got %v", err)
+	}
+}
+
+func TestSelfClosingElements(t *testing.T) {
+	doc := testBook(t, true).Chapters[0].Document
+	if n := doc.Find("div.spacer, a#top").Children().Length(); n != 0 {
+		t.Errorf("self-closing elements must not contain later content, got %d children", n)
+	}
+	if doc.Find("body > p").Length() != 1 || doc.Find("body > a[href]").Length() != 1 {
+		t.Errorf("expected content after self-closing elements to stay in body")
+	}
+	if doc.Find("p br").Length() != 1 || doc.Find("head > script + link").Length() != 1 {
+		t.Error("expected void and script elements to be kept")
+	}
+	if got := doc.Find("title").Text(); got != "Basics" {
+		t.Errorf("unexpected title %q", got)
+	}
+}
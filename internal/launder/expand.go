@@ -3,7 +3,7 @@ package launder
 import "golang.org/x/net/html"
 
 func (s *Selection) Add(selector string) *Selection {
-	return s.AddNodes(findWithMatcher([]*html.Node{s.document.rootNode}, compileMatcher(selector))...)
+	return s.AddNodes(findWithMatcher([]*html.Node{s.document.rootNode}, s.compile(selector))...)
 }
 
 func (s *Selection) AddMatcher(m Matcher) *Selection {
@@ -0,0 +1,353 @@
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/geistblitz/boringformat/internal/launder"
+	"github.com/geistblitz/boringformat/internal/launder/internal/textutil"
+	"golang.org/x/net/html/charset"
+)
+
+var ErrUnknownFormat = errors.New("feed: unknown format")
+
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+)
+
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	time.RFC822Z,
+	time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+type Feed struct {
+	Format      string
+	Title       string
+	Link        string
+	Description string
+	Updated     time.Time
+	Entries     []*Entry
+}
+
+type Entry struct {
+	ID         string
+	Title      string
+	Link       string
+	Authors    []string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+	Summary    string
+	Content    string
+	Document   *launder.Document
+}
+
+func Parse(r io.Reader) (*Feed, error) {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	dec.CharsetReader = charset.NewReaderLabel
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, ErrUnknownFormat
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "rss":
+			var doc rssDocument
+			if err := dec.DecodeElement(&doc, &start); err != nil {
+				return nil, fmt.Errorf("feed: %w", err)
+			}
+			return doc.feed()
+		case "feed":
+			var doc atomFeed
+			if err := dec.DecodeElement(&doc, &start); err != nil {
+				return nil, fmt.Errorf("feed: %w", err)
+			}
+			return doc.feed()
+		default:
+			return nil, fmt.Errorf("%w: root element %q", ErrUnknownFormat, start.Name.Local)
+		}
+	}
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title         string    `xml:"title"`
+		Links         []rssLink `xml:"link"`
+		Description   string    `xml:"description"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		PubDate       string    `xml:"pubDate"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssLink struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+func plainLink(links []rssLink) string {
+	for _, l := range links {
+		if l.XMLName.Space == "" {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+type rssItem struct {
+	Title       string    `xml:"title"`
+	Links       []rssLink `xml:"link"`
+	GUID        string    `xml:"guid"`
+	PubDate     string    `xml:"pubDate"`
+	Author      string    `xml:"author"`
+	Creators    []string  `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string  `xml:"category"`
+	Description string    `xml:"description"`
+	Encoded     string    `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+func (d *rssDocument) feed() (*Feed, error) {
+	ch := d.Channel
+	f := &Feed{
+		Format:      FormatRSS,
+		Title:       strings.TrimSpace(ch.Title),
+		Link:        strings.TrimSpace(plainLink(ch.Links)),
+		Description: strings.TrimSpace(ch.Description),
+		Updated:     parseDate(ch.LastBuildDate),
+	}
+	if f.Updated.IsZero() {
+		f.Updated = parseDate(ch.PubDate)
+	}
+	base := parseURL(nil, f.Link)
+
+	for _, it := range ch.Items {
+		e := &Entry{
+			ID:         strings.TrimSpace(it.GUID),
+			Title:      strings.TrimSpace(it.Title),
+			Link:       strings.TrimSpace(plainLink(it.Links)),
+			Categories: textutil.TrimAll(it.Categories),
+			Published:  parseDate(it.PubDate),
+			Summary:    it.Description,
+			Content:    it.Encoded,
+		}
+		if e.ID == "" {
+			e.ID = e.Link
+		}
+		e.Authors = textutil.TrimAll(append([]string{it.Author}, it.Creators...))
+		if err := e.parse(base); err != nil {
+			return nil, err
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	return f, nil
+}
+
+type atomFeed struct {
+	Base     string      `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Title    atomText    `xml:"title"`
+	Subtitle atomText    `xml:"subtitle"`
+	Links    []atomLink  `xml:"link"`
+	Updated  string      `xml:"updated"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Base       string       `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	ID         string       `xml:"id"`
+	Title      atomText     `xml:"title"`
+	Links      []atomLink   `xml:"link"`
+	Published  string       `xml:"published"`
+	Updated    string       `xml:"updated"`
+	Authors    []atomPerson `xml:"author"`
+	Categories []struct {
+		Term  string `xml:"term,attr"`
+		Label string `xml:"label,attr"`
+	} `xml:"category"`
+	Summary atomText `xml:"summary"`
+	Content atomText `xml:"content"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Src   string `xml:"src,attr"`
+	Body  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) html() string {
+	switch strings.ToLower(t.Type) {
+	case "html", "text/html":
+		return t.Body
+	case "xhtml":
+		return t.Inner
+	default:
+		return html.EscapeString(t.Body)
+	}
+}
+
+func (t atomText) text() string {
+	if strings.ToLower(t.Type) == "text" || t.Type == "" {
+		return strings.TrimSpace(t.Body)
+	}
+	doc, err := launder.NewDocumentFromReader(strings.NewReader(t.html()))
+	if err != nil {
+		return strings.TrimSpace(t.Body)
+	}
+	return strings.TrimSpace(doc.Text())
+}
+
+func alternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	return ""
+}
+
+func (d *atomFeed) feed() (*Feed, error) {
+	f := &Feed{
+		Format:      FormatAtom,
+		Title:       d.Title.text(),
+		Description: d.Subtitle.text(),
+		Updated:     parseDate(d.Updated),
+	}
+	feedBase := parseURL(nil, d.Base)
+	f.Link = resolve(feedBase, alternateLink(d.Links))
+	if feedBase == nil {
+		feedBase = parseURL(nil, f.Link)
+	}
+
+	for _, it := range d.Entries {
+		base := feedBase
+		if it.Base != "" {
+			base = parseURL(feedBase, it.Base)
+		}
+
+		e := &Entry{
+			ID:        strings.TrimSpace(it.ID),
+			Title:     it.Title.text(),
+			Link:      resolve(base, alternateLink(it.Links)),
+			Published: parseDate(it.Published),
+			Updated:   parseDate(it.Updated),
+			Summary:   it.Summary.html(),
+			Content:   it.Content.html(),
+		}
+		for _, a := range it.Authors {
+			if name := strings.TrimSpace(a.Name); name != "" {
+				e.Authors = append(e.Authors, name)
+			}
+		}
+		for _, c := range it.Categories {
+			if c.Label != "" {
+				e.Categories = append(e.Categories, c.Label)
+			} else if c.Term != "" {
+				e.Categories = append(e.Categories, c.Term)
+			}
+		}
+		if e.Published.IsZero() {
+			e.Published = e.Updated
+		}
+		if err := e.parse(base); err != nil {
+			return nil, err
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	return f, nil
+}
+
+func (e *Entry) parse(feedBase *url.URL) error {
+	body := e.Content
+	if strings.TrimSpace(body) == "" {
+		body = e.Summary
+	}
+
+	doc, err := launder.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("feed: entry %q: %w", e.ID, err)
+	}
+
+	base := feedBase
+	if e.Link != "" {
+		e.Link = resolve(feedBase, e.Link)
+		base = parseURL(nil, e.Link)
+	}
+	if base != nil {
+		doc.Url = base
+		doc.ResolveURLs(base)
+	}
+	e.Document = doc
+	return nil
+}
+
+func parseURL(base *url.URL, ref string) *url.URL {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	return u
+}
+
+func resolve(base *url.URL, ref string) string {
+	if u := parseURL(base, ref); u != nil {
+		return u.String()
+	}
+	return ref
+}
+
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,151 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+)
+
+const testRSS = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+  <title>Example Blog</title>
+  <link>https://example.com/</link>
+  <item>
+    <title>First post</title>
+    <link>https://example.com/posts/first/</link>
+    <pubDate>Tue, 02 Jan 2024 15:04:05 +0000</pubDate>
+    <dc:creator>Ada</dc:creator>
+    <category>go</category>
+    <description>Short summary</description>
+    <content:encoded><![CDATA[<p>Body with <a href="../second/">a link</a> and <img src="img/a.png" srcset="img/a.png 1x, /img/a@2x.png 2x"></p>]]></content:encoded>
+  </item>
+  <item>
+    <title>Summary only</title>
+    <link>/posts/summary/</link>
+    <description>&lt;p&gt;Only a &lt;a href="more"&gt;summary&lt;/a&gt;&lt;/p&gt;</description>
+  </item>
+</channel>
+</rss>`
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:base="https://atom.example.org/blog/">
+  <title type="text">Atom Blog</title>
+  <link rel="self" href="feed.xml"/>
+  <link href="./"/>
+  <updated>2024-02-03T04:05:06Z</updated>
+  <entry>
+    <id>urn:1</id>
+    <title type="html">Hello &lt;em&gt;world&lt;/em&gt;</title>
+    <link rel="alternate" href="2024/hello"/>
+    <updated>2024-02-03T04:05:06Z</updated>
+    <author><name>Grace</name></author>
+    <category term="news" label="News"/>
+    <content type="html">&lt;p&gt;See &lt;a href="other"&gt;other&lt;/a&gt;&lt;/p&gt;</content>
+  </entry>
+  <entry>
+    <id>urn:2</id>
+    <title>XHTML entry</title>
+    <link href="https://elsewhere.net/x/y"/>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Inline <img src="pic.jpg"/></p></div></content>
+  </entry>
+</feed>`
+
+func TestParseRSS(t *testing.T) {
+	f, err := Parse(strings.NewReader(testRSS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Format != FormatRSS || f.Title != "Example Blog" || len(f.Entries) != 2 {
+		t.Fatalf("unexpected feed %+v", f)
+	}
+
+	e := f.Entries[0]
+	if e.Published.Year() != 2024 || len(e.Authors) != 1 || e.Authors[0] != "Ada" || e.Categories[0] != "go" {
+		t.Errorf("unexpected entry metadata %+v", e)
+	}
+	if href, _ := e.Document.Find("a").Attr("href"); href != "https://example.com/posts/second/" {
+		t.Errorf("unexpected resolved href %q", href)
+	}
+	if srcset, _ := e.Document.Find("img").Attr("srcset"); srcset != "https://example.com/posts/first/img/a.png 1x, https://example.com/img/a@2x.png 2x" {
+		t.Errorf("unexpected resolved srcset %q", srcset)
+	}
+
+	e = f.Entries[1]
+	if e.Link != "https://example.com/posts/summary/" {
+		t.Errorf("unexpected link %q", e.Link)
+	}
+	if href, _ := e.Document.Find("a").Attr("href"); href != "https://example.com/posts/summary/more" {
+		t.Errorf("unexpected summary href %q", href)
+	}
+}
+
+func TestParseAtom(t *testing.T) {
+	f, err := Parse(strings.NewReader(testAtom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Format != FormatAtom || f.Title != "Atom Blog" || f.Link != "https://atom.example.org/blog/" || len(f.Entries) != 2 {
+		t.Fatalf("unexpected feed %+v", f)
+	}
+
+	e := f.Entries[0]
+	if e.Title != "Hello world" || e.Link != "https://atom.example.org/blog/2024/hello" || e.Authors[0] != "Grace" || e.Categories[0] != "News" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if href, _ := e.Document.Find("a").Attr("href"); href != "https://atom.example.org/blog/2024/other" {
+		t.Errorf("unexpected resolved href %q", href)
+	}
+
+	e = f.Entries[1]
+	if src, _ := e.Document.Find("p img").Attr("src"); src != "https://elsewhere.net/x/pic.jpg" {
+		t.Errorf("unexpected xhtml content %q", src)
+	}
+}
+
+func TestParseRSSAtomLink(t *testing.T) {
+	const src = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+<channel>
+  <title>WordPress Blog</title>
+  <atom:link href="https://example.com/feed/" rel="self" type="application/rss+xml"/>
+  <link>https://example.com/</link>
+  <atom:link href="https://hub.example.com/" rel="hub"/>
+  <item>
+    <title>Post</title>
+    <link>/p/1</link>
+    <atom:link href="https://example.com/p/1/amp" rel="amphtml"/>
+  </item>
+</channel>
+</rss>`
+	f, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Link != "https://example.com/" {
+		t.Errorf("unexpected channel link %q", f.Link)
+	}
+	if len(f.Entries) != 1 || f.Entries[0].Link != "https://example.com/p/1" {
+		t.Fatalf("unexpected entries %+v", f.Entries)
+	}
+}
+
+func TestParseLatin1(t *testing.T) {
+	src := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+		"<rss version=\"2.0\"><channel><title>Caf\xe9 news</title><link>https://example.com/</link>" +
+		"<item><title>Cr\xe8me br\xfbl\xe9e</title><link>https://example.com/creme/</link>" +
+		"<description>&lt;p&gt;Au caf\xe9&lt;/p&gt;</description></item></channel></rss>"
+	f, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Title != "Café news" || len(f.Entries) != 1 {
+		t.Fatalf("unexpected feed %+v", f)
+	}
+	e := f.Entries[0]
+	if e.Title != "Crème brûlée" {
+		t.Errorf("unexpected entry title %q", e.Title)
+	}
+	if got := e.Document.Find("p").Text(); got != "Au café" {
+		t.Errorf("unexpected body text %q", got)
+	}
+}
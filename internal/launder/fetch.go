@@ -0,0 +1,169 @@
+package launder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrBodyTooLarge     = errors.New("response body exceeds the maximum size")
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+const defaultMaxRedirects = 10
+
+var DefaultContentTypes = []string{
+	"text/html",
+	"application/xhtml+xml",
+}
+
+type FetchOptions struct {
+	Client        *http.Client
+	Header        http.Header
+	MaxBodySize   int64
+	MaxRedirects  int
+	CheckRedirect func(req *http.Request, via []*http.Request) error
+	ContentTypes  []string
+}
+
+func DefaultFetchOptions() *FetchOptions {
+	return &FetchOptions{
+		MaxBodySize:  10 << 20,
+		ContentTypes: DefaultContentTypes,
+	}
+}
+
+type StatusError struct {
+	StatusCode int
+	Status     string
+	URL        *url.URL
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %s: unexpected status %s", e.URL, e.Status)
+}
+
+type ContentTypeError struct {
+	ContentType string
+	URL         *url.URL
+}
+
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("GET %s: unsupported content type %q", e.URL, e.ContentType)
+}
+
+func NewDocumentWithOptions(ctx context.Context, url string, opts *FetchOptions) (*Document, error) {
+	if opts == nil {
+		opts = DefaultFetchOptions()
+	}
+
+	req, e := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if e != nil {
+		return nil, e
+	}
+	for k, vs := range opts.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
+	res, e := opts.client().Do(req)
+	if e != nil {
+		return nil, e
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		res.Body.Close()
+		return nil, &StatusError{StatusCode: res.StatusCode, Status: res.Status, URL: res.Request.URL}
+	}
+
+	if !opts.acceptContentType(res.Header.Get("Content-Type")) {
+		res.Body.Close()
+		return nil, &ContentTypeError{ContentType: res.Header.Get("Content-Type"), URL: res.Request.URL}
+	}
+
+	if opts.MaxBodySize > 0 {
+		res.Body = &limitedBody{ReadCloser: res.Body, remaining: opts.MaxBodySize}
+	}
+	return NewDocumentFromResponse(res)
+}
+
+func (opts *FetchOptions) client() *http.Client {
+	c := http.DefaultClient
+	if opts.Client != nil {
+		c = opts.Client
+	}
+
+	max := opts.MaxRedirects
+	if max == 0 && c.CheckRedirect == nil {
+		max = defaultMaxRedirects
+	}
+	check := opts.CheckRedirect
+	if check == nil && max != 0 {
+		check = func(req *http.Request, via []*http.Request) error {
+			if max < 0 {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= max {
+				return ErrTooManyRedirects
+			}
+			return nil
+		}
+	}
+	if check == nil {
+		return c
+	}
+
+	clone := *c
+	clone.CheckRedirect = check
+	return &clone
+}
+
+func (opts *FetchOptions) acceptContentType(ct string) bool {
+	if len(opts.ContentTypes) == 0 || ct == "" {
+		return true
+	}
+
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	for _, t := range opts.ContentTypes {
+		if strings.EqualFold(mt, t) {
+			return true
+		}
+	}
+	return false
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		var probe [1]byte
+		for {
+			n, err := b.ReadCloser.Read(probe[:])
+			if n > 0 {
+				return 0, ErrBodyTooLarge
+			}
+			if err != nil {
+				return 0, io.EOF
+			}
+		}
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,118 @@
+package launder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newFetchServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><body><p>` + r.Header.Get("X-Token") + `</p></body></html>`))
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<p>" + strings.Repeat("x", 4096) + "</p>"))
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewDocumentWithOptions(t *testing.T) {
+	srv := newFetchServer(t)
+	opts := DefaultFetchOptions()
+	opts.Client = srv.Client()
+	opts.Header = http.Header{"X-Token": {"secret"}}
+
+	doc, err := NewDocumentWithOptions(context.Background(), srv.URL+"/page", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txt := doc.Find("p").Text(); txt != "secret" {
+		t.Errorf("expected header to be sent, got %q", txt)
+	}
+
+	doc, err = NewDocumentWithOptions(context.Background(), srv.URL+"/redirect", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Url.Path != "/page" {
+		t.Errorf("expected final URL /page, got %s", doc.Url)
+	}
+}
+
+func TestNewDocumentWithOptionsErrors(t *testing.T) {
+	srv := newFetchServer(t)
+
+	_, err := NewDocumentWithOptions(context.Background(), srv.URL+"/missing", &FetchOptions{Client: srv.Client()})
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 StatusError, got %v", err)
+	}
+
+	_, err = NewDocumentWithOptions(context.Background(), srv.URL+"/redirect", &FetchOptions{Client: srv.Client(), MaxRedirects: -1})
+	if !errors.As(err, &se) || se.StatusCode != http.StatusFound {
+		t.Errorf("expected 302 StatusError, got %v", err)
+	}
+
+	errBlocked := errors.New("redirect blocked")
+	client := *srv.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return errBlocked
+	}
+	_, err = NewDocumentWithOptions(context.Background(), srv.URL+"/redirect", &FetchOptions{Client: &client})
+	if !errors.Is(err, errBlocked) {
+		t.Errorf("expected the client's redirect policy to be kept, got %v", err)
+	}
+	opts := DefaultFetchOptions()
+	opts.Client = &client
+	_, err = NewDocumentWithOptions(context.Background(), srv.URL+"/redirect", opts)
+	if !errors.Is(err, errBlocked) {
+		t.Errorf("expected the client's redirect policy to be kept with default options, got %v", err)
+	}
+	if _, err = NewDocumentWithOptions(context.Background(), srv.URL+"/redirect", &FetchOptions{Client: &client, MaxRedirects: 3}); err != nil {
+		t.Errorf("expected an explicit MaxRedirects to replace the client's policy, got %v", err)
+	}
+
+	_, err = NewDocumentWithOptions(context.Background(), srv.URL+"/json", &FetchOptions{Client: srv.Client(), ContentTypes: DefaultContentTypes})
+	var ce *ContentTypeError
+	if !errors.As(err, &ce) {
+		t.Errorf("expected ContentTypeError, got %v", err)
+	}
+
+	_, err = NewDocumentWithOptions(context.Background(), srv.URL+"/large", &FetchOptions{Client: srv.Client(), MaxBodySize: 1024})
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("expected ErrBodyTooLarge, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = NewDocumentWithOptions(ctx, srv.URL+"/slow", &FetchOptions{Client: srv.Client()})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -3,7 +3,7 @@ package launder
 import "golang.org/x/net/html"
 
 func (s *Selection) Filter(selector string) *Selection {
-	return s.FilterMatcher(compileMatcher(selector))
+	return s.FilterMatcher(s.compile(selector))
 }
 
 func (s *Selection) FilterMatcher(m Matcher) *Selection {
@@ -11,7 +11,7 @@ func (s *Selection) FilterMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) Not(selector string) *Selection {
-	return s.NotMatcher(compileMatcher(selector))
+	return s.NotMatcher(s.compile(selector))
 }
 
 func (s *Selection) NotMatcher(m Matcher) *Selection {
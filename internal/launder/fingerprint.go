@@ -0,0 +1,399 @@
+package launder
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+type FingerprintOptions struct {
+	TextShingle      int
+	StructureShingle int
+	NumHashes        int
+	Bands            int
+	Threshold        float64
+}
+
+func DefaultFingerprintOptions() *FingerprintOptions {
+	return &FingerprintOptions{
+		TextShingle:      3,
+		StructureShingle: 4,
+		NumHashes:        128,
+		Bands:            32,
+		Threshold:        0.8,
+	}
+}
+
+type MinHash []uint64
+
+type Fingerprint struct {
+	SimHash uint64
+	MinHash MinHash
+}
+
+func (s *Selection) TextFingerprint(opts *FingerprintOptions) *Fingerprint {
+	if opts == nil {
+		opts = DefaultFingerprintOptions()
+	}
+	return NewFingerprint(shingles(textTokens(s.Text()), opts.TextShingle), opts.NumHashes)
+}
+
+func (s *Selection) StructureFingerprint(opts *FingerprintOptions) *Fingerprint {
+	if opts == nil {
+		opts = DefaultFingerprintOptions()
+	}
+	return NewFingerprint(shingles(structureTokens(s.Nodes), opts.StructureShingle), opts.NumHashes)
+}
+
+func TextFingerprint(text string, opts *FingerprintOptions) *Fingerprint {
+	if opts == nil {
+		opts = DefaultFingerprintOptions()
+	}
+	return NewFingerprint(shingles(textTokens(text), opts.TextShingle), opts.NumHashes)
+}
+
+func NewFingerprint(features []string, numHashes int) *Fingerprint {
+	hashes := make([]uint64, len(features))
+	for i, f := range features {
+		hashes[i] = featureHash(f)
+	}
+	return &Fingerprint{
+		SimHash: simHash(hashes),
+		MinHash: minHash(hashes, numHashes),
+	}
+}
+
+func (f *Fingerprint) Similarity(other *Fingerprint) float64 {
+	if f == nil || other == nil {
+		return 0
+	}
+	return f.MinHash.Similarity(other.MinHash)
+}
+
+func (f *Fingerprint) SimHashSimilarity(other *Fingerprint) float64 {
+	if f == nil || other == nil {
+		return 0
+	}
+	return 1 - float64(HammingDistance(f.SimHash, other.SimHash))/64
+}
+
+func (m MinHash) Similarity(other MinHash) float64 {
+	n := len(m)
+	if len(other) < n {
+		n = len(other)
+	}
+	if n == 0 {
+		return 0
+	}
+
+	same := 0
+	for i := 0; i < n; i++ {
+		if m[i] == other[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(n)
+}
+
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func simHash(hashes []uint64) uint64 {
+	var weights [64]int
+	for _, h := range hashes {
+		for i := 0; i < 64; i++ {
+			if h&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	var result uint64
+	for i, w := range weights {
+		if w > 0 {
+			result |= 1 << uint(i)
+		}
+	}
+	return result
+}
+
+func minHash(hashes []uint64, numHashes int) MinHash {
+	if numHashes <= 0 || len(hashes) == 0 {
+		return nil
+	}
+
+	sig := make(MinHash, numHashes)
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for _, h := range hashes {
+		for i := range sig {
+			if v := mix64(h ^ minHashSeed(i)); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+func minHashSeed(i int) uint64 {
+	return mix64(uint64(i+1) * 0x9e3779b97f4a7c15)
+}
+
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func featureHash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func textTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func structureTokens(nodes []*html.Node) []string {
+	var tokens []string
+	var f func(n *html.Node, parent string)
+	f = func(n *html.Node, parent string) {
+		if n.Type != html.ElementNode && n.Type != html.DocumentNode {
+			return
+		}
+		tag := parent
+		if n.Type == html.ElementNode {
+			tag = n.Data
+			tokens = append(tokens, parent+">"+tag)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c, tag)
+		}
+	}
+	for _, n := range nodes {
+		f(n, "")
+	}
+	return tokens
+}
+
+func shingles(tokens []string, size int) []string {
+	if size <= 0 {
+		size = 1
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+	if len(tokens) <= size {
+		return []string{strings.Join(tokens, " ")}
+	}
+
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(tokens)-size+1)
+	for i := 0; i+size <= len(tokens); i++ {
+		s := strings.Join(tokens[i:i+size], " ")
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+var ErrNilFingerprint = errors.New("fingerprint is nil")
+
+type DuplicateMatch struct {
+	Key        string
+	Similarity float64
+}
+
+type DuplicateIndex struct {
+	opts    FingerprintOptions
+	mu      sync.RWMutex
+	entries map[string]*Fingerprint
+	order   []string
+	buckets map[uint64][]string
+}
+
+func NewDuplicateIndex(opts *FingerprintOptions) *DuplicateIndex {
+	if opts == nil {
+		opts = DefaultFingerprintOptions()
+	}
+	return &DuplicateIndex{
+		opts:    *opts,
+		entries: make(map[string]*Fingerprint),
+		buckets: make(map[uint64][]string),
+	}
+}
+
+func (x *DuplicateIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.entries)
+}
+
+func (x *DuplicateIndex) Add(key string, f *Fingerprint) error {
+	if f == nil {
+		return ErrNilFingerprint
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if _, ok := x.entries[key]; ok {
+		x.removeLocked(key)
+	}
+	x.entries[key] = f
+	x.order = append(x.order, key)
+	for _, b := range x.bands(f.MinHash) {
+		x.buckets[b] = append(x.buckets[b], key)
+	}
+	return nil
+}
+
+func (x *DuplicateIndex) Remove(key string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.removeLocked(key)
+}
+
+func (x *DuplicateIndex) removeLocked(key string) {
+	f, ok := x.entries[key]
+	if !ok {
+		return
+	}
+	delete(x.entries, key)
+	for i, k := range x.order {
+		if k == key {
+			x.order = append(x.order[:i], x.order[i+1:]...)
+			break
+		}
+	}
+	for _, b := range x.bands(f.MinHash) {
+		keys := x.buckets[b]
+		for i, k := range keys {
+			if k == key {
+				keys = append(keys[:i], keys[i+1:]...)
+				break
+			}
+		}
+		if len(keys) == 0 {
+			delete(x.buckets, b)
+		} else {
+			x.buckets[b] = keys
+		}
+	}
+}
+
+func (x *DuplicateIndex) Query(f *Fingerprint) []DuplicateMatch {
+	if f == nil {
+		return nil
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.queryLocked(f, "")
+}
+
+func (x *DuplicateIndex) queryLocked(f *Fingerprint, skip string) []DuplicateMatch {
+	seen := make(map[string]bool)
+	var result []DuplicateMatch
+	for _, b := range x.bands(f.MinHash) {
+		for _, k := range x.buckets[b] {
+			if seen[k] || k == skip {
+				continue
+			}
+			seen[k] = true
+			if sim := f.Similarity(x.entries[k]); sim >= x.opts.Threshold {
+				result = append(result, DuplicateMatch{Key: k, Similarity: sim})
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Similarity != result[j].Similarity {
+			return result[i].Similarity > result[j].Similarity
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+func (x *DuplicateIndex) Groups() [][]string {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	parent := make(map[string]string, len(x.order))
+	var find func(string) string
+	find = func(k string) string {
+		if p := parent[k]; p != k {
+			parent[k] = find(p)
+		}
+		return parent[k]
+	}
+	for _, k := range x.order {
+		parent[k] = k
+	}
+	for _, k := range x.order {
+		for _, m := range x.queryLocked(x.entries[k], k) {
+			if a, b := find(k), find(m.Key); a != b {
+				parent[b] = a
+			}
+		}
+	}
+
+	index := make(map[string]int)
+	var groups [][]string
+	for _, k := range x.order {
+		root := find(k)
+		i, ok := index[root]
+		if !ok {
+			i = len(groups)
+			index[root] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], k)
+	}
+
+	result := groups[:0]
+	for _, g := range groups {
+		if len(g) > 1 {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+func (x *DuplicateIndex) bands(sig MinHash) []uint64 {
+	bands := x.opts.Bands
+	if bands <= 0 || bands > len(sig) {
+		bands = len(sig)
+	}
+	if bands == 0 {
+		return nil
+	}
+
+	rows := len(sig) / bands
+	result := make([]uint64, bands)
+	for b := 0; b < bands; b++ {
+		h := mix64(uint64(b) + 1)
+		for _, v := range sig[b*rows : (b+1)*rows] {
+			h = mix64(h ^ v)
+		}
+		result[b] = h
+	}
+	return result
+}
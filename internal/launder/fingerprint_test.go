@@ -0,0 +1,163 @@
+package launder
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const fingerprintBase = `The quick brown fox jumps over the lazy dog while the farmer watches from the porch. Later that evening the fox returns to the farm looking for chickens, but the dog has moved the hens into the barn and locked the door behind them before going to sleep near the fire.`
+
+const fingerprintUnrelated = `Quarterly revenue grew by twelve percent as the company expanded into new markets across Asia and Europe, driven by strong demand for cloud services, improved margins in hardware, and a series of acquisitions completed during the second half of the fiscal year.`
+
+func fingerprintNearDuplicate() string {
+	return strings.Replace(fingerprintBase, "chickens", "eggs", 1)
+}
+
+func TestSimHashDistance(t *testing.T) {
+	base := TextFingerprint(fingerprintBase, nil)
+	near := TextFingerprint(fingerprintNearDuplicate(), nil)
+	other := TextFingerprint(fingerprintUnrelated, nil)
+
+	dNear := HammingDistance(base.SimHash, near.SimHash)
+	dOther := HammingDistance(base.SimHash, other.SimHash)
+	if dNear > 10 {
+		t.Errorf("near duplicate too far apart: %d bits", dNear)
+	}
+	if dOther < 20 {
+		t.Errorf("unrelated text too close: %d bits", dOther)
+	}
+	if base.SimHashSimilarity(near) <= base.SimHashSimilarity(other) {
+		t.Error("expected the near duplicate to be more similar")
+	}
+	if HammingDistance(base.SimHash, TextFingerprint(fingerprintBase, nil).SimHash) != 0 {
+		t.Error("fingerprints must be deterministic")
+	}
+}
+
+func TestMinHashJaccard(t *testing.T) {
+	a := shingles(textTokens(fingerprintBase), 3)
+	b := shingles(textTokens(fingerprintNearDuplicate()), 3)
+
+	set := make(map[string]bool)
+	for _, s := range a {
+		set[s] = true
+	}
+	inter := 0
+	for _, s := range b {
+		if set[s] {
+			inter++
+		}
+	}
+	exact := float64(inter) / float64(len(a)+len(b)-inter)
+
+	opts := DefaultFingerprintOptions()
+	opts.NumHashes = 256
+	est := NewFingerprint(a, opts.NumHashes).Similarity(NewFingerprint(b, opts.NumHashes))
+	if math.Abs(est-exact) > 0.1 {
+		t.Errorf("MinHash estimate %.3f too far from Jaccard %.3f", est, exact)
+	}
+	if sim := TextFingerprint(fingerprintBase, nil).Similarity(TextFingerprint(fingerprintUnrelated, nil)); sim > 0.1 {
+		t.Errorf("unrelated texts estimated at %.3f", sim)
+	}
+	if (MinHash{}).Similarity(MinHash{1}) != 0 {
+		t.Error("empty signatures must have zero similarity")
+	}
+
+	var none *Fingerprint
+	base := TextFingerprint(fingerprintBase, nil)
+	if base.Similarity(nil) != 0 || none.Similarity(base) != 0 || none.Similarity(nil) != 0 {
+		t.Error("nil fingerprints must have zero similarity")
+	}
+	if base.SimHashSimilarity(nil) != 0 || none.SimHashSimilarity(base) != 0 {
+		t.Error("nil fingerprints must have zero SimHash similarity")
+	}
+}
+
+func TestDuplicateIndex(t *testing.T) {
+	opts := DefaultFingerprintOptions()
+	opts.Threshold = 0.5
+	x := NewDuplicateIndex(opts)
+
+	if err := x.Add("nil", nil); err != ErrNilFingerprint {
+		t.Errorf("expected ErrNilFingerprint, got %v", err)
+	}
+	if x.Query(nil) != nil {
+		t.Error("expected no matches for a nil fingerprint")
+	}
+
+	docs := map[string]string{
+		"a": fingerprintBase,
+		"b": fingerprintNearDuplicate(),
+		"c": fingerprintUnrelated,
+		"d": strings.Replace(fingerprintUnrelated, "twelve", "nine", 1),
+	}
+	for _, k := range []string{"a", "b", "c", "d"} {
+		if err := x.Add(k, TextFingerprint(docs[k], opts)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if x.Len() != 4 {
+		t.Fatalf("expected 4 entries, got %d", x.Len())
+	}
+
+	matches := x.Query(TextFingerprint(fingerprintBase, opts))
+	if len(matches) != 2 || matches[0].Key != "a" || matches[0].Similarity != 1 || matches[1].Key != "b" {
+		t.Errorf("unexpected matches %+v", matches)
+	}
+
+	groups := x.Groups()
+	if len(groups) != 2 || strings.Join(groups[0], ",") != "a,b" || strings.Join(groups[1], ",") != "c,d" {
+		t.Errorf("unexpected groups %v", groups)
+	}
+
+	x.Remove("b")
+	x.Remove("missing")
+	if x.Len() != 3 {
+		t.Errorf("expected 3 entries after removal, got %d", x.Len())
+	}
+	if m := x.Query(TextFingerprint(fingerprintNearDuplicate(), opts)); len(m) != 1 || m[0].Key != "a" {
+		t.Errorf("unexpected matches after removal %+v", m)
+	}
+	if groups := x.Groups(); len(groups) != 1 {
+		t.Errorf("unexpected groups after removal %v", groups)
+	}
+	for _, keys := range x.buckets {
+		for _, k := range keys {
+			if k == "b" {
+				t.Fatal("removed key left in LSH buckets")
+			}
+		}
+	}
+}
+
+func TestLSHBands(t *testing.T) {
+	opts := DefaultFingerprintOptions()
+	x := NewDuplicateIndex(opts)
+	sig := TextFingerprint(fingerprintBase, opts).MinHash
+
+	bands := x.bands(sig)
+	if len(bands) != opts.Bands {
+		t.Fatalf("expected %d bands, got %d", opts.Bands, len(bands))
+	}
+
+	changed := append(MinHash(nil), sig...)
+	changed[0]++
+	other := x.bands(changed)
+	if other[0] == bands[0] {
+		t.Error("changing a row must change its band")
+	}
+	for i := 1; i < len(bands); i++ {
+		if other[i] != bands[i] {
+			t.Errorf("band %d changed although its rows did not", i)
+		}
+	}
+
+	x.opts.Bands = 0
+	if len(x.bands(sig)) != len(sig) {
+		t.Error("expected one band per row when Bands is unset")
+	}
+	if x.bands(nil) != nil {
+		t.Error("expected no bands for an empty signature")
+	}
+}
@@ -0,0 +1,307 @@
+package launder
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type InjectionLocation int
+
+const (
+	LocationHidden InjectionLocation = iota
+	LocationComment
+	LocationAttribute
+	LocationTinyFont
+	LocationOffScreen
+	LocationTagCharacters
+	LocationZeroWidth
+	LocationVisible
+)
+
+var injectionLocationNames = []string{
+	LocationHidden:        "hidden",
+	LocationComment:       "comment",
+	LocationAttribute:     "attribute",
+	LocationTinyFont:      "tiny-font",
+	LocationOffScreen:     "off-screen",
+	LocationTagCharacters: "tag-characters",
+	LocationZeroWidth:     "zero-width",
+	LocationVisible:       "visible",
+}
+
+func (l InjectionLocation) String() string {
+	if l >= 0 && int(l) < len(injectionLocationNames) {
+		return injectionLocationNames[l]
+	}
+	return "unknown"
+}
+
+var DefaultInjectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget|skip|override)\b.{0,40}\b(previous|prior|above|earlier|preceding|all|any|your|system)\b.{0,20}\b(instructions?|prompts?|messages?|directions?|rules|guidelines|context)\b`),
+	regexp.MustCompile(`(?i)\byou are now\b`),
+	regexp.MustCompile(`(?i)\b(new|updated|real|actual) (system )?instructions?\s*:`),
+	regexp.MustCompile(`(?i)\b(system|developer) (prompt|message|instructions?)\b`),
+	regexp.MustCompile(`(?i)\b(do not|don't|never) (tell|inform|mention|reveal)\b.{0,20}\b(the )?user\b`),
+	regexp.MustCompile(`(?i)\b(reveal|print|repeat|output|show)\b.{0,20}\b(your|the) (system prompt|instructions|hidden prompt)\b`),
+	regexp.MustCompile(`(?i)\b(ai|assistant|llm|language model|chatbot|gpt|claude)s?\b\s*[,:]\s*(please\s+)?(ignore|disregard|forget|respond|reply|output|say|write|recommend)\b`),
+	regexp.MustCompile(`(?i)<\|?\s*(im_start|im_end|system|endoftext)\s*\|?>|\[/?(inst|sys)\]|<<\s*/?sys\s*>>`),
+}
+
+var defaultSkippedAttributes = map[string]bool{
+	"class":  true,
+	"id":     true,
+	"style":  true,
+	"href":   true,
+	"src":    true,
+	"srcset": true,
+	"action": true,
+	"rel":    true,
+	"type":   true,
+	"lang":   true,
+	"dir":    true,
+	"width":  true,
+	"height": true,
+}
+
+const minZeroWidthRun = 8
+
+type InjectionOptions struct {
+	Patterns       []*regexp.Regexp
+	SkipAttributes map[string]bool
+	Visibility     *VisibilityOptions
+	MinFontSize    float64
+	ScanVisible    bool
+	Strip          bool
+}
+
+func DefaultInjectionOptions() *InjectionOptions {
+	skip := make(map[string]bool, len(defaultSkippedAttributes))
+	for k, v := range defaultSkippedAttributes {
+		skip[k] = v
+	}
+	return &InjectionOptions{
+		Patterns:       DefaultInjectionPatterns,
+		SkipAttributes: skip,
+		Visibility:     DefaultVisibilityOptions(),
+		MinFontSize:    4,
+	}
+}
+
+type InjectionFinding struct {
+	Location InjectionLocation
+	Node     *html.Node
+	Path     string
+	Attr     string
+	Text     string
+	Match    string
+}
+
+func (d *Document) DetectInjections(opts *InjectionOptions) ([]InjectionFinding, error) {
+	if opts == nil {
+		opts = DefaultInjectionOptions()
+	}
+	if opts.Strip && d.readOnly {
+		return nil, ErrReadOnly
+	}
+	vis := opts.Visibility
+	if vis == nil {
+		vis = DefaultVisibilityOptions()
+	}
+
+	hidden := newVisibilityChecker(&VisibilityOptions{
+		Heuristics:    vis.Heuristics &^ HeuristicOffScreen,
+		HiddenClasses: vis.HiddenClasses,
+		Cascade:       vis.Cascade,
+	})
+	offScreen := newVisibilityChecker(&VisibilityOptions{
+		Heuristics: vis.Heuristics & HeuristicOffScreen,
+		Cascade:    vis.Cascade,
+	})
+	ia := &injectionAnalyzer{opts: opts, hidden: hidden, offScreen: offScreen}
+
+	ia.walk(d.rootNode)
+	if opts.Strip {
+		d.StripInjections(ia.findings)
+	}
+	return ia.findings, nil
+}
+
+func (d *Document) StripInjections(findings []InjectionFinding) *Document {
+	s, m := d.Selection.writable()
+	for _, f := range findings {
+		n := f.Node
+		if c, ok := m[n]; ok {
+			n = c
+		}
+		payload := f.Location == LocationTagCharacters || (f.Location == LocationZeroWidth && f.Match == "")
+		switch {
+		case f.Attr != "":
+			if payload {
+				if a := getAttributePtr(f.Attr, n); a != nil {
+					a.Val = stripInvisibleRunes(a.Val)
+				}
+			} else {
+				removeAttr(n, f.Attr)
+			}
+		case payload:
+			n.Data = stripInvisibleRunes(n.Data)
+		case n.Parent != nil:
+			n.Parent.RemoveChild(n)
+		}
+	}
+	return s.document
+}
+
+type injectionAnalyzer struct {
+	opts      *InjectionOptions
+	hidden    *visibilityChecker
+	offScreen *visibilityChecker
+	findings  []InjectionFinding
+}
+
+func (ia *injectionAnalyzer) walk(n *html.Node) {
+	switch n.Type {
+	case html.CommentNode:
+		ia.scan(n, "", n.Data, LocationComment)
+	case html.TextNode:
+		if p := n.Parent; p != nil && p.Type == html.ElementNode && (p.DataAtom == atom.Script || p.DataAtom == atom.Style) {
+			break
+		}
+		ia.scan(n, "", n.Data, ia.textLocation(n))
+	case html.ElementNode:
+		for _, a := range n.Attr {
+			if ia.opts.SkipAttributes[a.Key] {
+				continue
+			}
+			ia.scan(n, a.Key, a.Val, LocationAttribute)
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		ia.walk(c)
+	}
+}
+
+func (ia *injectionAnalyzer) textLocation(n *html.Node) InjectionLocation {
+	if ia.hidden.isHidden(n) {
+		return LocationHidden
+	}
+	if ia.offScreen.isHidden(n) {
+		return LocationOffScreen
+	}
+	if size, ok := ia.fontSize(n.Parent); ok && size < ia.opts.MinFontSize {
+		return LocationTinyFont
+	}
+	return LocationVisible
+}
+
+func (ia *injectionAnalyzer) fontSize(n *html.Node) (float64, bool) {
+	for ; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		if v, ok := parseCSSLength(ia.hidden.style(n).Get("font-size")); ok {
+			return v, true
+		}
+		if ia.hidden.opts.Cascade != nil {
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+func (ia *injectionAnalyzer) scan(n *html.Node, attr, text string, loc InjectionLocation) {
+	if text == "" {
+		return
+	}
+
+	add := func(loc InjectionLocation, text, match string) {
+		ia.findings = append(ia.findings, InjectionFinding{
+			Location: loc,
+			Node:     n,
+			Path:     NodePath(n),
+			Attr:     attr,
+			Text:     text,
+			Match:    match,
+		})
+	}
+
+	if tags := decodeTagCharacters(text); tags != "" {
+		add(LocationTagCharacters, tags, ia.match(tags))
+		return
+	}
+
+	if hasZeroWidthRun(text, minZeroWidthRun) {
+		add(LocationZeroWidth, text, "")
+		return
+	}
+
+	if m := ia.match(text); m != "" {
+		if loc == LocationVisible && !ia.opts.ScanVisible {
+			return
+		}
+		add(loc, strings.TrimSpace(text), m)
+		return
+	}
+
+	if cleaned := stripInvisibleRunes(text); cleaned != text {
+		if m := ia.match(cleaned); m != "" {
+			add(LocationZeroWidth, strings.TrimSpace(cleaned), m)
+		}
+	}
+}
+
+func (ia *injectionAnalyzer) match(text string) string {
+	for _, rx := range ia.opts.Patterns {
+		if m := rx.FindString(text); m != "" {
+			return m
+		}
+	}
+	return ""
+}
+
+func isTagCharacter(r rune) bool {
+	return r >= 0xE0000 && r <= 0xE007F
+}
+
+func isZeroWidth(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\u2060', '\u2061', '\u2062', '\u2063', '\u2064', '\ufeff', '\u180e':
+		return true
+	}
+	return false
+}
+
+func decodeTagCharacters(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if isTagCharacter(r) && r >= 0xE0020 && r <= 0xE007E {
+			b.WriteRune(r - 0xE0000)
+		}
+	}
+	return b.String()
+}
+
+func hasZeroWidthRun(s string, min int) bool {
+	run := 0
+	for _, r := range s {
+		if isZeroWidth(r) {
+			run++
+			if run >= min {
+				return true
+			}
+		} else {
+			run = 0
+		}
+	}
+	return false
+}
+
+func stripInvisibleRunes(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isTagCharacter(r) || isZeroWidth(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,13 @@
+package textutil
+
+import "strings"
+
+func TrimAll(values []string) []string {
+	var result []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,363 @@
+package launder
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/geistblitz/boringformat/internal/launder/parser"
+	"golang.org/x/net/html"
+)
+
+const (
+	maxLearnAtoms         = 12
+	maxLearnAncestorDepth = 6
+)
+
+var learnSkippedAttributes = map[string]bool{
+	"class": true,
+	"id":    true,
+	"style": true,
+}
+
+var learnPresenceOnlyAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"srcset": true,
+	"title":  true,
+	"alt":    true,
+	"value":  true,
+}
+
+type LearnOptions struct {
+	MaxAtoms         int
+	UnlabeledPenalty float64
+	MaxResults       int
+}
+
+func DefaultLearnOptions() *LearnOptions {
+	return &LearnOptions{
+		MaxAtoms:         3,
+		UnlabeledPenalty: 0.1,
+		MaxResults:       10,
+	}
+}
+
+type LearnedSelector struct {
+	Selector   string
+	Sel        parser.Sel
+	Precision  float64
+	Recall     float64
+	Complexity int
+	Positives  int
+	Negatives  int
+	Unlabeled  int
+}
+
+type SelectorLearner struct {
+	docs      []*Document
+	positives []*html.Node
+	labels    map[*html.Node]bool
+}
+
+func NewSelectorLearner() *SelectorLearner {
+	return &SelectorLearner{labels: make(map[*html.Node]bool)}
+}
+
+func (l *SelectorLearner) Positive(sel *Selection) *SelectorLearner {
+	return l.add(sel, true)
+}
+
+func (l *SelectorLearner) Negative(sel *Selection) *SelectorLearner {
+	return l.add(sel, false)
+}
+
+func (l *SelectorLearner) add(sel *Selection, positive bool) *SelectorLearner {
+	if sel == nil || sel.document == nil {
+		return l
+	}
+
+	known := false
+	for _, d := range l.docs {
+		if d == sel.document {
+			known = true
+			break
+		}
+	}
+	if !known {
+		l.docs = append(l.docs, sel.document)
+	}
+
+	for _, n := range sel.Nodes {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if prev, ok := l.labels[n]; ok && prev == positive {
+			continue
+		}
+		l.labels[n] = positive
+		if positive {
+			l.positives = append(l.positives, n)
+		}
+	}
+	return l
+}
+
+func (l *SelectorLearner) Learn(opts *LearnOptions) []LearnedSelector {
+	if opts == nil {
+		opts = DefaultLearnOptions()
+	}
+
+	var positives []*html.Node
+	for _, n := range l.positives {
+		if l.labels[n] {
+			positives = append(positives, n)
+		}
+	}
+	if len(positives) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var results []LearnedSelector
+	for _, c := range l.candidates(positives, opts) {
+		s := c.sel.String()
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+
+		r := l.evaluate(c, len(positives), opts)
+		if r.Positives == 0 {
+			continue
+		}
+		r.Selector = s
+		results = append(results, r)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if sa, sb := a.Precision*a.Recall, b.Precision*b.Recall; sa != sb {
+			return sa > sb
+		}
+		if a.Complexity != b.Complexity {
+			return a.Complexity < b.Complexity
+		}
+		if a.Unlabeled != b.Unlabeled {
+			return a.Unlabeled < b.Unlabeled
+		}
+		return a.Selector < b.Selector
+	})
+
+	if opts.MaxResults > 0 && len(results) > opts.MaxResults {
+		results = results[:opts.MaxResults]
+	}
+	return results
+}
+
+func (l *SelectorLearner) evaluate(c learnCandidate, positives int, opts *LearnOptions) LearnedSelector {
+	r := LearnedSelector{Sel: c.sel, Complexity: c.cost}
+	for _, d := range l.docs {
+		for _, n := range parser.QueryAll(d.rootNode, c.sel) {
+			if label, ok := l.labels[n]; ok {
+				if label {
+					r.Positives++
+				} else {
+					r.Negatives++
+				}
+			} else {
+				r.Unlabeled++
+			}
+		}
+	}
+
+	denom := float64(r.Positives+r.Negatives) + opts.UnlabeledPenalty*float64(r.Unlabeled)
+	if denom > 0 {
+		r.Precision = float64(r.Positives) / denom
+	}
+	r.Recall = float64(r.Positives) / float64(positives)
+	return r
+}
+
+type learnAtom struct {
+	key        string
+	sel        parser.Sel
+	cost       int
+	isTag      bool
+	positional bool
+}
+
+type learnCandidate struct {
+	sel  parser.Sel
+	cost int
+}
+
+func (l *SelectorLearner) candidates(positives []*html.Node, opts *LearnOptions) []learnCandidate {
+	self := commonAtoms(positives, selfAtoms)
+	if len(self) > maxLearnAtoms {
+		self = self[:maxLearnAtoms]
+	}
+
+	maxAtoms := opts.MaxAtoms
+	if maxAtoms <= 0 {
+		maxAtoms = 1
+	}
+
+	var bases []learnCandidate
+	var combine func(start int, picked []learnAtom)
+	combine = func(start int, picked []learnAtom) {
+		if len(picked) > 0 && !positionalOnly(picked) {
+			bases = append(bases, compoundCandidate(picked))
+		}
+		if len(picked) == maxAtoms {
+			return
+		}
+		for i := start; i < len(self); i++ {
+			combine(i+1, append(picked[:len(picked):len(picked)], self[i]))
+		}
+	}
+	combine(0, nil)
+
+	parents := make([]*html.Node, 0, len(positives))
+	for _, n := range positives {
+		if n.Parent == nil || n.Parent.Type != html.ElementNode {
+			parents = nil
+			break
+		}
+		parents = append(parents, n.Parent)
+	}
+	var parentAtoms []learnAtom
+	if len(parents) > 0 {
+		parentAtoms = commonAtoms(parents, contextAtoms)
+	}
+	ancestorAtoms := commonAtoms(positives, ancestorContextAtoms)
+
+	result := append([]learnCandidate(nil), bases...)
+	for _, b := range bases {
+		if b.cost > 2 {
+			continue
+		}
+		for _, p := range parentAtoms {
+			result = append(result, learnCandidate{parser.Child(p.sel, b.sel), b.cost + p.cost + 1})
+		}
+		for _, a := range ancestorAtoms {
+			result = append(result, learnCandidate{parser.Descendant(a.sel, b.sel), b.cost + a.cost + 1})
+		}
+	}
+	return result
+}
+
+func compoundCandidate(atoms []learnAtom) learnCandidate {
+	sels := make([]parser.Sel, 0, len(atoms))
+	cost := 0
+	for _, a := range atoms {
+		if a.isTag {
+			sels = append([]parser.Sel{a.sel}, sels...)
+		} else {
+			sels = append(sels, a.sel)
+		}
+		cost += a.cost
+	}
+	return learnCandidate{parser.Compound(sels...), cost}
+}
+
+func positionalOnly(atoms []learnAtom) bool {
+	hasTag, positional := false, false
+	for _, a := range atoms {
+		hasTag = hasTag || a.isTag
+		positional = positional || a.positional
+	}
+	return positional && !hasTag
+}
+
+func commonAtoms(nodes []*html.Node, features func(*html.Node) []learnAtom) []learnAtom {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	common := features(nodes[0])
+	for _, n := range nodes[1:] {
+		keys := make(map[string]bool)
+		for _, a := range features(n) {
+			keys[a.key] = true
+		}
+		kept := common[:0]
+		for _, a := range common {
+			if keys[a.key] {
+				kept = append(kept, a)
+			}
+		}
+		common = kept
+	}
+	return common
+}
+
+func selfAtoms(n *html.Node) []learnAtom {
+	atoms := []learnAtom{{key: "tag:" + n.Data, sel: parser.Tag(n.Data), cost: 1, isTag: true}}
+
+	for _, c := range stableClasses(n) {
+		atoms = append(atoms, learnAtom{key: "class:" + c, sel: parser.Class(c), cost: 1})
+	}
+
+	for _, a := range n.Attr {
+		if learnSkippedAttributes[a.Key] || a.Namespace != "" {
+			continue
+		}
+		if !learnPresenceOnlyAttributes[a.Key] && a.Val != "" && len(a.Val) <= 64 && !rxUnstableToken.MatchString(a.Val) {
+			atoms = append(atoms, learnAtom{key: "attr=" + a.Key + "=" + a.Val, sel: parser.AttrEquals(a.Key, a.Val), cost: 1})
+		}
+		atoms = append(atoms, learnAtom{key: "attr:" + a.Key, sel: parser.AttrExists(a.Key), cost: 1})
+	}
+
+	if id := stableID(n); id != "" {
+		atoms = append(atoms, learnAtom{key: "id:" + id, sel: parser.ID(id), cost: 1})
+	}
+
+	if n.Parent != nil && n.Parent.Type == html.ElementNode {
+		i, count := 0, 0
+		for c := n.Parent.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.Data == n.Data {
+				count++
+				if c == n {
+					i = count
+				}
+			}
+		}
+		atoms = append(atoms, learnAtom{key: "nth-of-type:" + strconv.Itoa(i), sel: parser.NthChild(0, i, false, true), cost: 2, positional: true})
+		if i == count {
+			atoms = append(atoms, learnAtom{key: "last-of-type", sel: parser.NthChild(0, 1, true, true), cost: 2, positional: true})
+		}
+	}
+
+	return atoms
+}
+
+func contextAtoms(n *html.Node) []learnAtom {
+	atoms := []learnAtom{{key: "tag:" + n.Data, sel: parser.Tag(n.Data), cost: 1, isTag: true}}
+	if id := stableID(n); id != "" {
+		atoms = append(atoms, learnAtom{key: "id:" + id, sel: parser.ID(id), cost: 1})
+	}
+	for _, c := range stableClasses(n) {
+		atoms = append(atoms, learnAtom{key: "class:" + c, sel: parser.Class(c), cost: 1})
+	}
+	return atoms
+}
+
+func ancestorContextAtoms(n *html.Node) []learnAtom {
+	var atoms []learnAtom
+	seen := make(map[string]bool)
+	depth := 0
+	for p := n.Parent; p != nil && p.Type == html.ElementNode && depth < maxLearnAncestorDepth; p = p.Parent {
+		depth++
+		if depth == 1 {
+			continue
+		}
+		for _, a := range contextAtoms(p) {
+			if a.isTag || seen[a.key] {
+				continue
+			}
+			seen[a.key] = true
+			atoms = append(atoms, a)
+		}
+	}
+	return atoms
+}
@@ -0,0 +1,47 @@
+package launder
+
+import "testing"
+
+func TestSelectorLearner(t *testing.T) {
+	a := loadString(t, `<div class="list"><div class="product"><h2 class="title">A</h2><span class="price">1</span></div><div class="ad"><h2 class="title">Ad</h2></div><div class="product"><h2 class="title">B</h2></div></div>`)
+	b := loadString(t, `<main><div class="product"><h2 class="title">C</h2></div><div class="ad"><h2 class="title">Ad 2</h2></div></main>`)
+
+	results := NewSelectorLearner().
+		Positive(a.Find(".product h2")).
+		Positive(b.Find(".product h2")).
+		Negative(a.Find(".ad h2")).
+		Negative(b.Find(".ad h2")).
+		Learn(nil)
+	if len(results) == 0 {
+		t.Fatal("expected learned selectors")
+	}
+
+	top := results[0]
+	if top.Selector != ".product > .title" {
+		t.Errorf("unexpected top selector %q", top.Selector)
+	}
+	if top.Precision != 1 || top.Recall != 1 || top.Positives != 3 || top.Negatives != 0 {
+		t.Errorf("unexpected top scores %+v", top)
+	}
+	for _, r := range results {
+		if r.Selector == "h2" && (r.Precision != 0.6 || r.Recall != 1 || r.Negatives != 2) {
+			t.Errorf("unexpected scores for h2: %+v", r)
+		}
+	}
+
+	c := loadString(t, `<section><div class="product"><h2 class="title">D</h2></div><div class="ad"><h2 class="title">Ad 3</h2></div></section>`)
+	if got := c.Find(top.Selector).Text(); got != "D" {
+		t.Errorf("learned selector matched %q on a new page", got)
+	}
+
+	partial := NewSelectorLearner().
+		Positive(a.Find(".product h2").First()).
+		Negative(a.Find(".ad h2")).
+		Learn(&LearnOptions{MaxAtoms: 1, UnlabeledPenalty: 0.5, MaxResults: 3})
+	if len(partial) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(partial))
+	}
+	if p := partial[0]; p.Selector != ".product > .title" || p.Unlabeled != 1 || p.Precision != 1/1.5 || p.Recall != 1 {
+		t.Errorf("expected unlabeled matches to be penalized: %+v", p)
+	}
+}
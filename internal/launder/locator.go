@@ -0,0 +1,209 @@
+package launder
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/geistblitz/boringformat/internal/launder/parser"
+	"golang.org/x/net/html"
+)
+
+var (
+	rxUnstableToken = regexp.MustCompile(`\d{3,}|^[:_]|^(css|sc|jsx|svelte|emotion)-|^(ember|yui_|ext-gen)\d|[-_][a-zA-Z]*\d[a-zA-Z0-9]{3,}$|^[0-9a-f]{8}-[0-9a-f]{4}-`)
+	rxStateClass    = regexp.MustCompile(`^(is|has)-|^(active|selected|current|open|opened|closed|collapsed|expanded|hover|focus|focused|visible|hidden|show|in|fade|disabled|enabled|checked|loading|loaded)$`)
+)
+
+var stableAttributes = []string{
+	"data-testid",
+	"data-test",
+	"data-qa",
+	"itemprop",
+	"name",
+	"role",
+	"aria-label",
+	"property",
+	"for",
+	"rel",
+	"type",
+}
+
+const maxStableClasses = 2
+
+func StableSelector(n *html.Node) string {
+	if n == nil || n.Type != html.ElementNode {
+		return ""
+	}
+
+	root := n
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	g := &selectorGenerator{root: root, cache: make(map[string]bool)}
+	return g.generate(n)
+}
+
+func (s *Selection) StableSelector() string {
+	if len(s.Nodes) == 0 {
+		return ""
+	}
+	return StableSelector(s.Nodes[0])
+}
+
+func (s *Selection) StableSelectors() []string {
+	result := make([]string, 0, len(s.Nodes))
+	for _, n := range s.Nodes {
+		result = append(result, StableSelector(n))
+	}
+	return result
+}
+
+type selectorGenerator struct {
+	root  *html.Node
+	cache map[string]bool
+}
+
+func (g *selectorGenerator) generate(n *html.Node) string {
+	suffix := ""
+	for cur := n; cur != nil && cur.Type == html.ElementNode; cur = cur.Parent {
+		cands := selectorCandidates(cur)
+		for _, c := range cands {
+			if sel := joinSelectors(c, suffix); g.unique(sel, n) {
+				return sel
+			}
+		}
+
+		step, positional := siblingSelector(cur, cands)
+		suffix = joinSelectors(step, suffix)
+		if !positional && g.unique(suffix, n) {
+			return suffix
+		}
+
+		for anc := cur.Parent; anc != nil && anc.Type == html.ElementNode; anc = anc.Parent {
+			if id := stableID(anc); id != "" {
+				if anc == cur.Parent {
+					break
+				}
+				if sel := "#" + parser.EscapeIdentifier(id) + " " + suffix; g.unique(sel, n) {
+					return sel
+				}
+				break
+			}
+		}
+	}
+	if g.unique(suffix, n) {
+		return suffix
+	}
+	return ""
+}
+
+func (g *selectorGenerator) unique(sel string, n *html.Node) bool {
+	if ok, found := g.cache[sel]; found {
+		return ok
+	}
+
+	ok := false
+	if m, err := parser.Compile(sel); err == nil {
+		matches := m.MatchAll(g.root)
+		ok = len(matches) == 1 && matches[0] == n
+	}
+	g.cache[sel] = ok
+	return ok
+}
+
+func joinSelectors(sel, suffix string) string {
+	if suffix == "" {
+		return sel
+	}
+	return sel + " > " + suffix
+}
+
+func selectorCandidates(n *html.Node) []string {
+	var cands []string
+	tag := parser.EscapeIdentifier(n.Data)
+
+	if id := stableID(n); id != "" {
+		cands = append(cands, "#"+parser.EscapeIdentifier(id))
+	}
+	cands = append(cands, tag)
+
+	for _, attr := range stableAttributes {
+		if v, ok := getAttributeValue(attr, n); ok && v != "" && len(v) <= 64 && !rxUnstableToken.MatchString(v) {
+			cands = append(cands, tag+"["+attr+"="+parser.QuoteString(v)+"]")
+		}
+	}
+
+	classes := stableClasses(n)
+	for _, c := range classes {
+		cands = append(cands, tag+"."+parser.EscapeIdentifier(c))
+	}
+	if len(classes) > 1 {
+		compound := tag
+		for _, c := range classes[:maxStableClasses] {
+			compound += "." + parser.EscapeIdentifier(c)
+		}
+		cands = append(cands, compound)
+	}
+
+	return cands
+}
+
+func siblingSelector(n *html.Node, cands []string) (string, bool) {
+	tag := parser.EscapeIdentifier(n.Data)
+	if n.Parent == nil || n.Parent.Type != html.ElementNode {
+		return tag, false
+	}
+
+	for _, c := range cands {
+		if strings.HasPrefix(c, "#") {
+			continue
+		}
+		m, err := parser.Compile(c)
+		if err != nil {
+			continue
+		}
+		count := 0
+		for s := n.Parent.FirstChild; s != nil; s = s.NextSibling {
+			if s.Type == html.ElementNode && m.Match(s) {
+				count++
+			}
+		}
+		if count == 1 {
+			return c, false
+		}
+	}
+
+	i := 1
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode && s.Data == n.Data {
+			i++
+		}
+	}
+	return tag + ":nth-of-type(" + strconv.Itoa(i) + ")", true
+}
+
+func stableID(n *html.Node) string {
+	id, ok := getAttributeValue("id", n)
+	if !ok || id == "" || len(id) > 64 || strings.ContainsAny(id, " \t\r\n\f") || rxUnstableToken.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+func stableClasses(n *html.Node) []string {
+	v, ok := getAttributeValue("class", n)
+	if !ok {
+		return nil
+	}
+
+	var classes []string
+	seen := make(map[string]bool)
+	for _, c := range strings.Fields(v) {
+		if seen[c] || len(c) > 48 || rxUnstableToken.MatchString(c) || rxStateClass.MatchString(c) {
+			continue
+		}
+		seen[c] = true
+		classes = append(classes, c)
+	}
+	return classes
+}
@@ -0,0 +1,57 @@
+package launder
+
+import "testing"
+
+func TestStableSelectorRoundTrip(t *testing.T) {
+	for _, d := range []*Document{Doc(), Doc2()} {
+		d.Find("body *").Each(func(i int, s *Selection) {
+			sel := s.StableSelector()
+			found := d.Find(sel)
+			if found.Length() != 1 || found.Get(0) != s.Get(0) {
+				t.Errorf("selector %q matched %d nodes", sel, found.Length())
+			}
+		})
+	}
+}
+
+func TestStableSelectorPreferences(t *testing.T) {
+	doc := loadString(t, `<html><body>
+<div id="main"><p class="lead css-1x2y3z">a</p><p class="lead">b</p></div>
+<div id="123"><span class="7up">c</span></div>
+<ul><li>x</li><li data-testid="second item">y</li><li>z</li></ul>
+</body></html>`)
+
+	cases := []struct {
+		sel  string
+		want string
+	}{
+		{"#main", "#main"},
+		{"#main p", "#main > p:nth-of-type(1)"},
+		{"span", "span"},
+		{"li[data-testid]", `li[data-testid="second item"]`},
+		{"li:last-child", "ul > li:nth-of-type(3)"},
+	}
+	for _, c := range cases {
+		got := doc.Find(c.sel).StableSelector()
+		if got != c.want {
+			t.Errorf("%s: expected %q, got %q", c.sel, c.want, got)
+		}
+	}
+
+	n := doc.Find(`div[id="123"]`)
+	if sel := n.StableSelector(); doc.Find(sel).Get(0) != n.Get(0) {
+		t.Errorf("selector %q does not round-trip", sel)
+	}
+}
+
+func TestStableSelectorUnmatchable(t *testing.T) {
+	doc := loadString(t, `<html><body><svg><foreignObject>x</foreignObject><foreignObject></foreignObject></svg></body></html>`)
+	if sel := doc.Find("svg").Children().First().StableSelector(); sel != "" {
+		t.Errorf("expected no selector for an element no selector matches, got %q", sel)
+	}
+
+	_, m := doc.Find("svg").Children().First().TextWithOffsets()
+	if _, err := m.Serialize(); err == nil {
+		t.Error("expected an error when a span's parent has no selector")
+	}
+}
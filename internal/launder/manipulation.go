@@ -7,7 +7,7 @@ import (
 )
 
 func (s *Selection) After(selector string) *Selection {
-	return s.AfterMatcher(compileMatcher(selector))
+	return s.AfterMatcher(s.compile(selector))
 }
 
 func (s *Selection) AfterMatcher(m Matcher) *Selection {
@@ -15,6 +15,9 @@ func (s *Selection) AfterMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) AfterSelection(sel *Selection) *Selection {
+	s, m := s.writable()
+	sel = s.adopt(sel, m)
+	s.importSelection(sel)
 	return s.AfterNodes(sel.Nodes...)
 }
 
@@ -38,7 +41,7 @@ func (s *Selection) AfterNodes(ns ...*html.Node) *Selection {
 }
 
 func (s *Selection) Append(selector string) *Selection {
-	return s.AppendMatcher(compileMatcher(selector))
+	return s.AppendMatcher(s.compile(selector))
 }
 
 func (s *Selection) AppendMatcher(m Matcher) *Selection {
@@ -46,6 +49,9 @@ func (s *Selection) AppendMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) AppendSelection(sel *Selection) *Selection {
+	s, m := s.writable()
+	sel = s.adopt(sel, m)
+	s.importSelection(sel)
 	return s.AppendNodes(sel.Nodes...)
 }
 
@@ -64,7 +70,7 @@ func (s *Selection) AppendNodes(ns ...*html.Node) *Selection {
 }
 
 func (s *Selection) Before(selector string) *Selection {
-	return s.BeforeMatcher(compileMatcher(selector))
+	return s.BeforeMatcher(s.compile(selector))
 }
 
 func (s *Selection) BeforeMatcher(m Matcher) *Selection {
@@ -72,6 +78,9 @@ func (s *Selection) BeforeMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) BeforeSelection(sel *Selection) *Selection {
+	s, m := s.writable()
+	sel = s.adopt(sel, m)
+	s.importSelection(sel)
 	return s.BeforeNodes(sel.Nodes...)
 }
 
@@ -95,11 +104,18 @@ func (s *Selection) BeforeNodes(ns ...*html.Node) *Selection {
 
 func (s *Selection) Clone() *Selection {
 	ns := newEmptySelection(s.document)
+	if s.document != nil && s.document.readOnly {
+		ns.document = newDocument(&html.Node{Type: html.DocumentNode}, s.document.Url)
+	}
 	ns.Nodes = cloneNodes(s.Nodes)
+	for i, n := range s.Nodes {
+		ns.document.importData(s.document, n, ns.Nodes[i])
+	}
 	return ns
 }
 
 func (s *Selection) Empty() *Selection {
+	s, _ = s.writable()
 	var nodes []*html.Node
 
 	for _, n := range s.Nodes {
@@ -113,7 +129,7 @@ func (s *Selection) Empty() *Selection {
 }
 
 func (s *Selection) Prepend(selector string) *Selection {
-	return s.PrependMatcher(compileMatcher(selector))
+	return s.PrependMatcher(s.compile(selector))
 }
 
 func (s *Selection) PrependMatcher(m Matcher) *Selection {
@@ -121,6 +137,9 @@ func (s *Selection) PrependMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) PrependSelection(sel *Selection) *Selection {
+	s, m := s.writable()
+	sel = s.adopt(sel, m)
+	s.importSelection(sel)
 	return s.PrependNodes(sel.Nodes...)
 }
 
@@ -140,6 +159,7 @@ func (s *Selection) PrependNodes(ns ...*html.Node) *Selection {
 }
 
 func (s *Selection) Remove() *Selection {
+	s, _ = s.writable()
 	for _, n := range s.Nodes {
 		if n.Parent != nil {
 			n.Parent.RemoveChild(n)
@@ -150,7 +170,7 @@ func (s *Selection) Remove() *Selection {
 }
 
 func (s *Selection) RemoveFiltered(selector string) *Selection {
-	return s.RemoveMatcher(compileMatcher(selector))
+	return s.RemoveMatcher(s.compile(selector))
 }
 
 func (s *Selection) RemoveMatcher(m Matcher) *Selection {
@@ -158,7 +178,7 @@ func (s *Selection) RemoveMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) ReplaceWith(selector string) *Selection {
-	return s.ReplaceWithMatcher(compileMatcher(selector))
+	return s.ReplaceWithMatcher(s.compile(selector))
 }
 
 func (s *Selection) ReplaceWithMatcher(m Matcher) *Selection {
@@ -166,10 +186,14 @@ func (s *Selection) ReplaceWithMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) ReplaceWithSelection(sel *Selection) *Selection {
+	s, m := s.writable()
+	sel = s.adopt(sel, m)
+	s.importSelection(sel)
 	return s.ReplaceWithNodes(sel.Nodes...)
 }
 
 func (s *Selection) ReplaceWithHtml(htmlStr string) *Selection {
+	s, _ = s.writable()
 	s.eachNodeHtml(htmlStr, true, func(node *html.Node, nodes []*html.Node) {
 		nextSibling := node.NextSibling
 		for _, n := range nodes {
@@ -182,11 +206,14 @@ func (s *Selection) ReplaceWithHtml(htmlStr string) *Selection {
 }
 
 func (s *Selection) ReplaceWithNodes(ns ...*html.Node) *Selection {
+	s, m := s.writable()
+	ns = thawNodes(ns, m)
 	s.AfterNodes(ns...)
 	return s.Remove()
 }
 
 func (s *Selection) SetHtml(htmlStr string) *Selection {
+	s, _ = s.writable()
 	for _, context := range s.Nodes {
 		for c := context.FirstChild; c != nil; c = context.FirstChild {
 			context.RemoveChild(c)
@@ -204,6 +231,7 @@ func (s *Selection) SetText(text string) *Selection {
 }
 
 func (s *Selection) Unwrap() *Selection {
+	s, _ = s.writable()
 	s.Parent().Each(func(i int, ss *Selection) {
 		if ss.Nodes[0].Data != "body" {
 			ss.ReplaceWithSelection(ss.Contents())
@@ -214,7 +242,7 @@ func (s *Selection) Unwrap() *Selection {
 }
 
 func (s *Selection) Wrap(selector string) *Selection {
-	return s.WrapMatcher(compileMatcher(selector))
+	return s.WrapMatcher(s.compile(selector))
 }
 
 func (s *Selection) WrapMatcher(m Matcher) *Selection {
@@ -222,10 +250,14 @@ func (s *Selection) WrapMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) WrapSelection(sel *Selection) *Selection {
+	s, m := s.writable()
+	sel = s.adopt(sel, m)
+	s.importSelection(sel)
 	return s.wrapNodes(sel.Nodes...)
 }
 
 func (s *Selection) WrapHtml(htmlStr string) *Selection {
+	s, _ = s.writable()
 	nodesMap := make(map[string][]*html.Node)
 	for _, context := range s.Nodes {
 		var parent *html.Node
@@ -249,6 +281,8 @@ func (s *Selection) WrapNode(n *html.Node) *Selection {
 }
 
 func (s *Selection) wrapNodes(ns ...*html.Node) *Selection {
+	s, m := s.writable()
+	ns = thawNodes(ns, m)
 	s.Each(func(i int, ss *Selection) {
 		ss.wrapAllNodes(ns...)
 	})
@@ -257,7 +291,7 @@ func (s *Selection) wrapNodes(ns ...*html.Node) *Selection {
 }
 
 func (s *Selection) WrapAll(selector string) *Selection {
-	return s.WrapAllMatcher(compileMatcher(selector))
+	return s.WrapAllMatcher(s.compile(selector))
 }
 
 func (s *Selection) WrapAllMatcher(m Matcher) *Selection {
@@ -265,6 +299,9 @@ func (s *Selection) WrapAllMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) WrapAllSelection(sel *Selection) *Selection {
+	s, m := s.writable()
+	sel = s.adopt(sel, m)
+	s.importSelection(sel)
 	return s.wrapAllNodes(sel.Nodes...)
 }
 
@@ -290,11 +327,16 @@ func (s *Selection) wrapAllNodes(ns ...*html.Node) *Selection {
 }
 
 func (s *Selection) WrapAllNode(n *html.Node) *Selection {
+	s, m := s.writable()
+	if c, ok := m[n]; ok {
+		n = c
+	}
 	if s.Size() == 0 {
 		return s
 	}
 
 	wrap := cloneNode(n)
+	s.document.copyData(n, wrap)
 
 	first := s.Nodes[0]
 	if first.Parent != nil {
@@ -312,7 +354,7 @@ func (s *Selection) WrapAllNode(n *html.Node) *Selection {
 }
 
 func (s *Selection) WrapInner(selector string) *Selection {
-	return s.WrapInnerMatcher(compileMatcher(selector))
+	return s.WrapInnerMatcher(s.compile(selector))
 }
 
 func (s *Selection) WrapInnerMatcher(m Matcher) *Selection {
@@ -320,10 +362,14 @@ func (s *Selection) WrapInnerMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) WrapInnerSelection(sel *Selection) *Selection {
+	s, m := s.writable()
+	sel = s.adopt(sel, m)
+	s.importSelection(sel)
 	return s.wrapInnerNodes(sel.Nodes...)
 }
 
 func (s *Selection) WrapInnerHtml(htmlStr string) *Selection {
+	s, _ = s.writable()
 	nodesMap := make(map[string][]*html.Node)
 	for _, context := range s.Nodes {
 		nodes, found := nodesMap[nodeName(context)]
@@ -341,6 +387,8 @@ func (s *Selection) WrapInnerNode(n *html.Node) *Selection {
 }
 
 func (s *Selection) wrapInnerNodes(ns ...*html.Node) *Selection {
+	s, m := s.writable()
+	ns = thawNodes(ns, m)
 	if len(ns) == 0 {
 		return s
 	}
@@ -410,6 +458,8 @@ func cloneNode(n *html.Node) *html.Node {
 
 func (s *Selection) manipulateNodes(ns []*html.Node, reverse bool,
 	f func(sn *html.Node, n *html.Node)) *Selection {
+	s, m := s.writable()
+	ns = thawNodes(ns, m)
 
 	lasti := s.Size() - 1
 
@@ -422,7 +472,9 @@ func (s *Selection) manipulateNodes(ns []*html.Node, reverse bool,
 	for i, sn := range s.Nodes {
 		for _, n := range ns {
 			if i != lasti {
-				f(sn, cloneNode(n))
+				c := cloneNode(n)
+				s.document.copyData(n, c)
+				f(sn, c)
 			} else {
 				if n.Parent != nil {
 					n.Parent.RemoveChild(n)
@@ -436,6 +488,7 @@ func (s *Selection) manipulateNodes(ns []*html.Node, reverse bool,
 }
 
 func (s *Selection) eachNodeHtml(htmlStr string, isParent bool, mergeFn func(n *html.Node, nodes []*html.Node)) *Selection {
+	s, _ = s.writable()
 	nodeCache := make(map[string][]*html.Node)
 	var context *html.Node
 	for _, n := range s.Nodes {
@@ -0,0 +1,625 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	rxATX          = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?[ \t]*$`)
+	rxATXClose     = regexp.MustCompile(`(?:^|[ \t]+)#+[ \t]*$`)
+	rxHR           = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	rxFence        = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*(.*?)[ \t]*$")
+	rxSetext1      = regexp.MustCompile(`^ {0,3}=+[ \t]*$`)
+	rxSetext2      = regexp.MustCompile(`^ {0,3}-+[ \t]*$`)
+	rxBullet       = regexp.MustCompile(`^( {0,3})([-+*])( *)(.*)$`)
+	rxOrdered      = regexp.MustCompile(`^( {0,3})(\d{1,9})([.)])( *)(.*)$`)
+	rxBlockquote   = regexp.MustCompile(`^ {0,3}> ?`)
+	rxTableDelim   = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	rxRefDef       = regexp.MustCompile(`^ {0,3}\[((?:[^\]\\]|\\.){1,999})\]:[ \t]*(?:<([^>]*)>|(\S+))(?:[ \t]+("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|\((?:[^)\\]|\\.)*\)))?[ \t]*$`)
+	rxHTMLRaw      = regexp.MustCompile(`(?i)^ {0,3}<(script|pre|style|textarea)(?:\s|>|$)`)
+	rxHTMLComment  = regexp.MustCompile(`^ {0,3}<!--`)
+	rxHTMLBlock    = regexp.MustCompile(`(?i)^ {0,3}</?(address|article|aside|base|basefont|blockquote|body|caption|center|col|colgroup|dd|details|dialog|dir|div|dl|dt|fieldset|figcaption|figure|footer|form|frame|frameset|h[1-6]|head|header|hr|html|iframe|legend|li|link|main|menu|menuitem|nav|noframes|ol|optgroup|option|p|param|search|section|summary|table|tbody|td|tfoot|th|thead|title|tr|track|ul)(?:\s|/?>|$)`)
+	rxHTMLTagLine  = regexp.MustCompile(`^ {0,3}(?:<[A-Za-z][A-Za-z0-9-]*(?:\s+[a-zA-Z_:][a-zA-Z0-9_.:-]*(?:\s*=\s*(?:[^\s"'=<>` + "`" + `]+|'[^']*'|"[^"]*"))?)*\s*/?>|</[A-Za-z][A-Za-z0-9-]*\s*>)[ \t]*$`)
+	rxHTMLRawClose = regexp.MustCompile(`(?i)</(script|pre|style|textarea)>`)
+)
+
+type linkRef struct {
+	dest  string
+	title string
+}
+
+type inlineJob struct {
+	node *html.Node
+	text string
+	rng  LineRange
+}
+
+type blockParser struct {
+	refs  map[string]linkRef
+	lines map[*html.Node]LineRange
+	jobs  []inlineJob
+	tight map[*html.Node]bool
+}
+
+func indentOf(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " "))
+}
+
+func isBlank(s string) bool {
+	return strings.TrimSpace(s) == ""
+}
+
+func stripIndent(s string, n int) string {
+	i := 0
+	for i < n && i < len(s) && s[i] == ' ' {
+		i++
+	}
+	return s[i:]
+}
+
+func (p *blockParser) add(parent, n *html.Node, start, end int) {
+	parent.AppendChild(n)
+	p.lines[n] = LineRange{Start: start, End: end}
+}
+
+func (p *blockParser) parseBlocks(lines []mdLine, parent *html.Node) bool {
+	blankBetween := false
+	sawBlank := false
+	blocks := 0
+
+	for i := 0; i < len(lines); {
+		l := lines[i]
+		if isBlank(l.text) {
+			sawBlank = true
+			i++
+			continue
+		}
+		if sawBlank && blocks > 0 {
+			blankBetween = true
+		}
+		sawBlank = false
+		blocks++
+		i = p.parseBlock(lines, i, parent)
+	}
+	return blankBetween
+}
+
+func (p *blockParser) parseBlock(lines []mdLine, i int, parent *html.Node) int {
+	l := lines[i]
+	t := l.text
+
+	if indentOf(t) >= 4 {
+		return p.parseIndentedCode(lines, i, parent)
+	}
+	if m := rxFence.FindStringSubmatch(t); m != nil && !(m[2][0] == '`' && strings.Contains(m[3], "`")) {
+		return p.parseFencedCode(lines, i, parent, len(m[1]), m[2], m[3])
+	}
+	if m := rxATX.FindStringSubmatch(t); m != nil {
+		h := elementNamed("h" + strconv.Itoa(len(m[1])))
+		p.add(parent, h, l.num, l.num)
+		p.inline(h, strings.TrimSpace(rxATXClose.ReplaceAllString(m[2], "")), l.num, l.num)
+		return i + 1
+	}
+	if rxHR.MatchString(t) {
+		p.add(parent, element(atom.Hr), l.num, l.num)
+		return i + 1
+	}
+	if rxBlockquote.MatchString(t) {
+		return p.parseBlockquote(lines, i, parent)
+	}
+	if _, ok := listMarker(t); ok {
+		return p.parseList(lines, i, parent)
+	}
+	if htmlBlockStart(t, false) {
+		return p.parseHTMLBlock(lines, i, parent)
+	}
+	if i+1 < len(lines) && strings.Contains(t, "|") && rxTableDelim.MatchString(lines[i+1].text) {
+		if end, ok := p.parseTable(lines, i, parent); ok {
+			return end
+		}
+	}
+	return p.parseParagraph(lines, i, parent)
+}
+
+func (p *blockParser) parseIndentedCode(lines []mdLine, i int, parent *html.Node) int {
+	start := i
+	var content []string
+	last := i
+	for ; i < len(lines); i++ {
+		t := lines[i].text
+		if isBlank(t) {
+			content = append(content, stripIndent(t, 4))
+			continue
+		}
+		if indentOf(t) < 4 {
+			break
+		}
+		content = append(content, t[4:])
+		last = i
+	}
+	content = content[:last-start+1]
+
+	p.codeBlock(parent, strings.Join(content, "\n")+"\n", "", lines[start].num, lines[last].num)
+	return last + 1
+}
+
+func (p *blockParser) parseFencedCode(lines []mdLine, i int, parent *html.Node, indent int, fence, info string) int {
+	start := i
+	var content []string
+	closed := false
+	for i++; i < len(lines); i++ {
+		t := lines[i].text
+		if indentOf(t) < 4 {
+			trimmed := strings.TrimSpace(t)
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				closed = true
+				break
+			}
+		}
+		content = append(content, stripIndent(t, indent))
+	}
+
+	end := i
+	if end >= len(lines) {
+		end = len(lines) - 1
+	}
+	text := strings.Join(content, "\n")
+	if len(content) > 0 {
+		text += "\n"
+	}
+
+	lang := ""
+	if f := strings.Fields(unescapeString(info)); len(f) > 0 {
+		lang = f[0]
+	}
+	p.codeBlock(parent, text, lang, lines[start].num, lines[end].num)
+
+	if closed {
+		return i + 1
+	}
+	return len(lines)
+}
+
+func (p *blockParser) codeBlock(parent *html.Node, text, lang string, start, end int) {
+	pre := element(atom.Pre)
+	code := element(atom.Code)
+	if lang != "" {
+		code.Attr = []html.Attribute{{Key: "class", Val: "language-" + lang}}
+	}
+	pre.AppendChild(code)
+	if text != "" {
+		code.AppendChild(&html.Node{Type: html.TextNode, Data: text})
+	}
+	p.add(parent, pre, start, end)
+	setLines(p.lines, pre, LineRange{Start: start, End: end})
+}
+
+func (p *blockParser) parseBlockquote(lines []mdLine, i int, parent *html.Node) int {
+	start := i
+	var content []mdLine
+	paragraph := false
+	for ; i < len(lines); i++ {
+		t := lines[i].text
+		if loc := rxBlockquote.FindStringIndex(t); loc != nil {
+			rest := t[loc[1]:]
+			content = append(content, mdLine{text: rest, num: lines[i].num})
+			paragraph = !isBlank(rest) && indentOf(rest) < 4 && !rxFence.MatchString(rest)
+			continue
+		}
+		if paragraph && !isBlank(t) && !interruptsParagraph(t) {
+			content = append(content, lines[i])
+			continue
+		}
+		break
+	}
+
+	bq := element(atom.Blockquote)
+	p.add(parent, bq, lines[start].num, lines[i-1].num)
+	p.parseBlocks(content, bq)
+	return i
+}
+
+type marker struct {
+	ordered bool
+	char    byte
+	number  int
+	indent  int
+	content string
+	empty   bool
+}
+
+func listMarker(t string) (marker, bool) {
+	if rxHR.MatchString(t) {
+		return marker{}, false
+	}
+
+	var m marker
+	var width, spaces int
+	var rest string
+	if g := rxBullet.FindStringSubmatch(t); g != nil {
+		if g[3] == "" && g[4] != "" {
+			return marker{}, false
+		}
+		m.char = g[2][0]
+		width = len(g[1]) + 1
+		spaces, rest = len(g[3]), g[4]
+	} else if g := rxOrdered.FindStringSubmatch(t); g != nil {
+		if g[4] == "" && g[5] != "" {
+			return marker{}, false
+		}
+		m.ordered = true
+		m.char = g[3][0]
+		m.number, _ = strconv.Atoi(g[2])
+		width = len(g[1]) + len(g[2]) + 1
+		spaces, rest = len(g[4]), g[5]
+	} else {
+		return marker{}, false
+	}
+
+	switch {
+	case rest == "":
+		m.indent = width + 1
+		m.empty = true
+	case spaces > 4:
+		m.indent = width + 1
+		m.content = strings.Repeat(" ", spaces-1) + rest
+	default:
+		m.indent = width + spaces
+		m.content = rest
+	}
+	return m, true
+}
+
+func (p *blockParser) parseList(lines []mdLine, i int, parent *html.Node) int {
+	first, _ := listMarker(lines[i].text)
+
+	list := element(atom.Ul)
+	if first.ordered {
+		list = element(atom.Ol)
+		if first.number != 1 {
+			list.Attr = []html.Attribute{{Key: "start", Val: strconv.Itoa(first.number)}}
+		}
+	}
+	parent.AppendChild(list)
+
+	loose := false
+	start := lines[i].num
+	end := start
+	var items []*html.Node
+	for i < len(lines) {
+		m, ok := listMarker(lines[i].text)
+		if !ok || m.ordered != first.ordered || m.char != first.char {
+			break
+		}
+
+		content := []mdLine{{text: m.content, num: lines[i].num}}
+		itemStart := lines[i].num
+		j := i + 1
+		lastText := i
+		paragraph := !m.empty
+		for ; j < len(lines); j++ {
+			t := lines[j].text
+			if isBlank(t) {
+				if m.empty && j == i+1 {
+					break
+				}
+				content = append(content, mdLine{text: "", num: lines[j].num})
+				paragraph = false
+				continue
+			}
+			if indentOf(t) >= m.indent {
+				rest := t[m.indent:]
+				content = append(content, mdLine{text: rest, num: lines[j].num})
+				lastText = j
+				paragraph = indentOf(rest) < 4 && !rxFence.MatchString(rest)
+				continue
+			}
+			if paragraph && !interruptsParagraph(t) {
+				content = append(content, lines[j])
+				lastText = j
+				continue
+			}
+			break
+		}
+		content = content[:lastText-i+1]
+
+		li := element(atom.Li)
+		p.add(list, li, itemStart, lines[lastText].num)
+		if p.parseBlocks(content, li) {
+			loose = true
+		}
+		items = append(items, li)
+		end = lines[lastText].num
+
+		i = lastText + 1
+		blank := false
+		for i < len(lines) && isBlank(lines[i].text) {
+			blank = true
+			i++
+		}
+		if blank && i < len(lines) {
+			if next, ok := listMarker(lines[i].text); ok && next.ordered == first.ordered && next.char == first.char {
+				loose = true
+			}
+		}
+	}
+
+	p.lines[list] = LineRange{Start: start, End: end}
+	if !loose {
+		for _, li := range items {
+			for c := li.FirstChild; c != nil; c = c.NextSibling {
+				if c.DataAtom == atom.P {
+					p.tight[c] = true
+				}
+			}
+		}
+	}
+
+	for i > 0 && i <= len(lines) && lines[i-1].num > end && isBlank(lines[i-1].text) {
+		i--
+	}
+	return i
+}
+
+func htmlBlockStart(t string, inParagraph bool) bool {
+	if rxHTMLRaw.MatchString(t) || rxHTMLComment.MatchString(t) || rxHTMLBlock.MatchString(t) {
+		return true
+	}
+	return !inParagraph && rxHTMLTagLine.MatchString(t)
+}
+
+func (p *blockParser) parseHTMLBlock(lines []mdLine, i int, parent *html.Node) int {
+	start := i
+	t := lines[i].text
+
+	var until func(string) bool
+	switch {
+	case rxHTMLRaw.MatchString(t):
+		until = func(s string) bool { return rxHTMLRawClose.MatchString(s) }
+	case rxHTMLComment.MatchString(t):
+		until = func(s string) bool { return strings.Contains(s, "-->") }
+	}
+
+	var raw []string
+	for ; i < len(lines); i++ {
+		s := lines[i].text
+		if until == nil && isBlank(s) {
+			break
+		}
+		raw = append(raw, s)
+		if until != nil && until(s) {
+			i++
+			break
+		}
+	}
+
+	r := LineRange{Start: lines[start].num, End: lines[i-1].num}
+	nodes, err := html.ParseFragment(strings.NewReader(strings.Join(raw, "\n")), parent)
+	if err != nil {
+		return i
+	}
+	for _, n := range nodes {
+		parent.AppendChild(n)
+		setLines(p.lines, n, r)
+	}
+	return i
+}
+
+func (p *blockParser) parseTable(lines []mdLine, i int, parent *html.Node) (int, bool) {
+	header := splitRow(lines[i].text)
+	aligns := splitRow(lines[i+1].text)
+	if len(header) != len(aligns) {
+		return i, false
+	}
+	for k, a := range aligns {
+		a = strings.TrimSpace(a)
+		switch {
+		case strings.HasPrefix(a, ":") && strings.HasSuffix(a, ":"):
+			aligns[k] = "center"
+		case strings.HasPrefix(a, ":"):
+			aligns[k] = "left"
+		case strings.HasSuffix(a, ":"):
+			aligns[k] = "right"
+		default:
+			aligns[k] = ""
+		}
+	}
+
+	table := element(atom.Table)
+	thead := element(atom.Thead)
+	start := lines[i].num
+	p.add(table, thead, start, lines[i+1].num)
+	p.tableRow(thead, header, aligns, atom.Th, lines[i].num)
+
+	var tbody *html.Node
+	end := i + 1
+	for j := i + 2; j < len(lines); j++ {
+		t := lines[j].text
+		if isBlank(t) || rxBlockquote.MatchString(t) || rxATX.MatchString(t) || rxFence.MatchString(t) || rxHR.MatchString(t) {
+			break
+		}
+		if tbody == nil {
+			tbody = element(atom.Tbody)
+			table.AppendChild(tbody)
+		}
+		p.tableRow(tbody, splitRow(t), aligns, atom.Td, lines[j].num)
+		end = j
+	}
+	if tbody != nil {
+		p.lines[tbody] = LineRange{Start: lines[i+2].num, End: lines[end].num}
+	}
+
+	p.add(parent, table, start, lines[end].num)
+	return end + 1, true
+}
+
+func (p *blockParser) tableRow(parent *html.Node, cells, aligns []string, cellAtom atom.Atom, num int) {
+	tr := element(atom.Tr)
+	p.add(parent, tr, num, num)
+	for k := range aligns {
+		cell := element(cellAtom)
+		if aligns[k] != "" {
+			cell.Attr = []html.Attribute{{Key: "align", Val: aligns[k]}}
+		}
+		p.add(tr, cell, num, num)
+		if k < len(cells) {
+			p.inline(cell, strings.TrimSpace(cells[k]), num, num)
+		}
+	}
+}
+
+func splitRow(t string) []string {
+	t = strings.TrimSpace(t)
+	t = strings.TrimPrefix(t, "|")
+	if strings.HasSuffix(t, "|") && !strings.HasSuffix(t, `\|`) {
+		t = t[:len(t)-1]
+	}
+
+	var cells []string
+	var cur strings.Builder
+	for i := 0; i < len(t); i++ {
+		switch {
+		case t[i] == '\\' && i+1 < len(t) && t[i+1] == '|':
+			cur.WriteByte('|')
+			i++
+		case t[i] == '|':
+			cells = append(cells, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(t[i])
+		}
+	}
+	return append(cells, cur.String())
+}
+
+func interruptsParagraph(t string) bool {
+	if indentOf(t) >= 4 {
+		return false
+	}
+	if rxATX.MatchString(t) || rxHR.MatchString(t) || rxBlockquote.MatchString(t) || htmlBlockStart(t, true) {
+		return true
+	}
+	if m := rxFence.FindStringSubmatch(t); m != nil && !(m[2][0] == '`' && strings.Contains(m[3], "`")) {
+		return true
+	}
+	if m, ok := listMarker(t); ok && !m.empty && (!m.ordered || m.number == 1) {
+		return true
+	}
+	return false
+}
+
+func (p *blockParser) parseParagraph(lines []mdLine, i int, parent *html.Node) int {
+	start := i
+	text := []string{strings.TrimSpace(lines[i].text)}
+	level := 0
+	for i++; i < len(lines); i++ {
+		t := lines[i].text
+		if isBlank(t) {
+			break
+		}
+		if rxSetext1.MatchString(t) {
+			level = 1
+			break
+		}
+		if rxSetext2.MatchString(t) {
+			level = 2
+			break
+		}
+		if interruptsParagraph(t) {
+			break
+		}
+		text = append(text, strings.TrimLeft(t, " "))
+	}
+	end := i - 1
+
+	refEnd := 0
+	for refEnd < len(text) {
+		m := rxRefDef.FindStringSubmatch(text[refEnd])
+		if m == nil {
+			break
+		}
+		label := normalizeLabel(m[1])
+		if _, ok := p.refs[label]; !ok && label != "" {
+			dest := m[2]
+			if dest == "" {
+				dest = m[3]
+			}
+			title := ""
+			if len(m[4]) >= 2 {
+				title = m[4][1 : len(m[4])-1]
+			}
+			p.refs[label] = linkRef{dest: unescapeString(dest), title: unescapeString(title)}
+		}
+		refEnd++
+	}
+	text = text[refEnd:]
+
+	if len(text) == 0 {
+		if level != 0 {
+			return p.parseParagraphFallback(lines, i, parent)
+		}
+		return i
+	}
+	first := lines[start+refEnd].num
+
+	content := strings.TrimRight(strings.Join(text, "\n"), " \t")
+	if level != 0 {
+		h := elementNamed("h" + strconv.Itoa(level))
+		p.add(parent, h, first, lines[i].num)
+		p.inline(h, content, first, lines[i].num)
+		return i + 1
+	}
+
+	para := element(atom.P)
+	p.add(parent, para, first, lines[end].num)
+	p.inline(para, content, first, lines[end].num)
+	return i
+}
+
+func (p *blockParser) parseParagraphFallback(lines []mdLine, i int, parent *html.Node) int {
+	if rxHR.MatchString(lines[i].text) {
+		p.add(parent, element(atom.Hr), lines[i].num, lines[i].num)
+		return i + 1
+	}
+	return p.parseParagraph(lines, i, parent)
+}
+
+func (p *blockParser) inline(n *html.Node, text string, start, end int) {
+	p.jobs = append(p.jobs, inlineJob{node: n, text: text, rng: LineRange{Start: start, End: end}})
+}
+
+func (p *blockParser) runInlines() {
+	for _, job := range p.jobs {
+		markup := renderInlines(job.text, p.refs)
+		target := job.node
+		if p.tight[job.node] {
+			target = job.node.Parent
+		}
+
+		nodes, err := html.ParseFragment(strings.NewReader(markup), target)
+		if err != nil {
+			continue
+		}
+		for _, n := range nodes {
+			if target == job.node {
+				job.node.AppendChild(n)
+			} else {
+				target.InsertBefore(n, job.node)
+			}
+			setLines(p.lines, n, job.rng)
+		}
+		if target != job.node {
+			target.RemoveChild(job.node)
+			delete(p.lines, job.node)
+		}
+	}
+}
+
+func normalizeLabel(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,491 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	rxEntity    = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+	rxAutolink  = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*)>`)
+	rxEmail     = regexp.MustCompile("^<([a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>")
+	rxRawHTML   = regexp.MustCompile(`^(?:<[A-Za-z][A-Za-z0-9-]*(?:\s+[a-zA-Z_:][a-zA-Z0-9_.:-]*(?:\s*=\s*(?:[^\s"'=<>` + "`" + `]+|'[^']*'|"[^"]*"))?)*\s*/?>|</[A-Za-z][A-Za-z0-9-]*\s*>|(?s:<!--.*?-->)|(?s:<\?.*?\?>)|<![A-Za-z][^>]*>|(?s:<!\[CDATA\[.*?\]\]>))`)
+	rxTagStrip  = regexp.MustCompile(`<[^>]*>`)
+	specialRune = "\\`*_![]<&\n"
+)
+
+type inlineToken struct {
+	text     string
+	delim    byte
+	count    int
+	orig     int
+	canOpen  bool
+	canClose bool
+	open     []string
+	close    []string
+	image    bool
+	bracket  bool
+	active   bool
+	srcStart int
+}
+
+func (t *inlineToken) emphasis() bool {
+	return (t.delim == '*' || t.delim == '_') && (t.canOpen || t.canClose)
+}
+
+func (t *inlineToken) render(b *strings.Builder) {
+	switch {
+	case t.bracket:
+		if t.image {
+			b.WriteString("![")
+		} else {
+			b.WriteString("[")
+		}
+	case t.delim != 0:
+		for _, c := range t.close {
+			b.WriteString(c)
+		}
+		b.WriteString(strings.Repeat(string(t.delim), t.count))
+		for _, o := range t.open {
+			b.WriteString(o)
+		}
+	default:
+		b.WriteString(t.text)
+	}
+}
+
+func renderTokens(tokens []*inlineToken) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		t.render(&b)
+	}
+	return b.String()
+}
+
+func renderInlines(s string, refs map[string]linkRef) string {
+	var tokens []*inlineToken
+	literal := func(text string) {
+		tokens = append(tokens, &inlineToken{text: text})
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch c {
+		case '\\':
+			switch {
+			case i+1 < len(s) && s[i+1] == '\n':
+				literal("<br />\n")
+				i = skipSpaces(s, i+2)
+			case i+1 < len(s) && isASCIIPunct(s[i+1]):
+				literal(escapeText(s[i+1 : i+2]))
+				i += 2
+			default:
+				literal("\\")
+				i++
+			}
+
+		case '`':
+			n := runLength(s, i, '`')
+			if end := findCodeClose(s, i+n, n); end >= 0 {
+				literal("<code>" + escapeText(codeContent(s[i+n:end])) + "</code>")
+				i = end + n
+			} else {
+				literal(strings.Repeat("`", n))
+				i += n
+			}
+
+		case '*', '_':
+			n := runLength(s, i, c)
+			prev, next := runeBefore(s, i), runeAfter(s, i+n)
+			left := !unicode.IsSpace(next) && (!isPunct(next) || unicode.IsSpace(prev) || isPunct(prev))
+			right := !unicode.IsSpace(prev) && (!isPunct(prev) || unicode.IsSpace(next) || isPunct(next))
+			t := &inlineToken{delim: c, count: n, orig: n}
+			if c == '*' {
+				t.canOpen, t.canClose = left, right
+			} else {
+				t.canOpen = left && (!right || isPunct(prev))
+				t.canClose = right && (!left || isPunct(next))
+			}
+			tokens = append(tokens, t)
+			i += n
+
+		case '!':
+			if i+1 < len(s) && s[i+1] == '[' {
+				tokens = append(tokens, &inlineToken{bracket: true, image: true, active: true, srcStart: i + 2})
+				i += 2
+			} else {
+				literal("!")
+				i++
+			}
+
+		case '[':
+			tokens = append(tokens, &inlineToken{bracket: true, active: true, srcStart: i + 1})
+			i++
+
+		case ']':
+			tokens, i = closeBracket(tokens, s, i, refs)
+
+		case '<':
+			if m := rxAutolink.FindStringSubmatch(s[i:]); m != nil {
+				literal(`<a href="` + escapeText(m[1]) + `">` + escapeText(m[1]) + "</a>")
+				i += len(m[0])
+			} else if m := rxEmail.FindStringSubmatch(s[i:]); m != nil {
+				literal(`<a href="mailto:` + escapeText(m[1]) + `">` + escapeText(m[1]) + "</a>")
+				i += len(m[0])
+			} else if m := rxRawHTML.FindString(s[i:]); m != "" {
+				literal(m)
+				i += len(m)
+			} else {
+				literal("&lt;")
+				i++
+			}
+
+		case '&':
+			if m := rxEntity.FindString(s[i:]); m != "" {
+				literal(m)
+				i += len(m)
+			} else {
+				literal("&amp;")
+				i++
+			}
+
+		case '\n':
+			hard := false
+			if l := len(tokens); l > 0 && tokens[l-1].delim == 0 && !tokens[l-1].bracket {
+				last := tokens[l-1]
+				trimmed := strings.TrimRight(last.text, " ")
+				hard = len(last.text)-len(trimmed) >= 2
+				last.text = trimmed
+			}
+			if hard {
+				literal("<br />\n")
+			} else {
+				literal("\n")
+			}
+			i = skipSpaces(s, i+1)
+
+		default:
+			j := i + 1
+			for j < len(s) && !strings.ContainsRune(specialRune, rune(s[j])) {
+				j++
+			}
+			literal(escapeText(s[i:j]))
+			i = j
+		}
+	}
+
+	processEmphasis(tokens)
+	return renderTokens(tokens)
+}
+
+func closeBracket(tokens []*inlineToken, s string, i int, refs map[string]linkRef) ([]*inlineToken, int) {
+	b := -1
+	for k := len(tokens) - 1; k >= 0; k-- {
+		if tokens[k].bracket {
+			b = k
+			break
+		}
+	}
+	if b < 0 {
+		return append(tokens, &inlineToken{text: "]"}), i + 1
+	}
+
+	opener := tokens[b]
+	if !opener.active {
+		opener.bracket = false
+		opener.text = "["
+		return append(tokens, &inlineToken{text: "]"}), i + 1
+	}
+
+	dest, title, end, ok := parseLinkTail(s, i+1)
+	if !ok {
+		label := s[opener.srcStart:i]
+		end = i + 1
+		if i+1 < len(s) && s[i+1] == '[' {
+			if k := strings.IndexByte(s[i+2:], ']'); k >= 0 {
+				if inner := s[i+2 : i+2+k]; strings.TrimSpace(inner) != "" {
+					label = inner
+				}
+				end = i + 2 + k + 1
+			}
+		}
+		if ref, found := refs[normalizeLabel(label)]; found && strings.TrimSpace(label) != "" {
+			dest, title, ok = ref.dest, ref.title, true
+		} else {
+			end = i + 1
+		}
+	}
+
+	if !ok {
+		opener.bracket = false
+		if opener.image {
+			opener.text = "!["
+		} else {
+			opener.text = "["
+		}
+		return append(tokens, &inlineToken{text: "]"}), i + 1
+	}
+
+	inner := tokens[b+1:]
+	processEmphasis(inner)
+	for _, t := range inner {
+		t.canOpen, t.canClose = false, false
+	}
+
+	attrs := ""
+	if title != "" {
+		attrs = ` title="` + escapeText(title) + `"`
+	}
+
+	if opener.image {
+		alt := rxTagStrip.ReplaceAllString(renderTokens(inner), "")
+		img := &inlineToken{text: `<img src="` + escapeText(dest) + `" alt="` + strings.ReplaceAll(alt, `"`, "&quot;") + `"` + attrs + ` />`}
+		return append(tokens[:b], img), end
+	}
+
+	opener.bracket = false
+	opener.text = `<a href="` + escapeText(dest) + `"` + attrs + `>`
+	for _, t := range tokens[:b] {
+		if t.bracket && !t.image {
+			t.active = false
+		}
+	}
+	return append(tokens, &inlineToken{text: "</a>"}), end
+}
+
+func processEmphasis(tokens []*inlineToken) {
+	for c := 0; c < len(tokens); c++ {
+		closer := tokens[c]
+		if !closer.emphasis() || !closer.canClose {
+			continue
+		}
+
+		for closer.count > 0 {
+			o := -1
+			for k := c - 1; k >= 0; k-- {
+				t := tokens[k]
+				if !t.emphasis() || !t.canOpen || t.delim != closer.delim || t.count == 0 {
+					continue
+				}
+				if (t.canClose || closer.canOpen) && (t.orig+closer.orig)%3 == 0 && !(t.orig%3 == 0 && closer.orig%3 == 0) {
+					continue
+				}
+				o = k
+				break
+			}
+			if o < 0 {
+				break
+			}
+
+			opener := tokens[o]
+			use, tag := 1, "em"
+			if closer.count >= 2 && opener.count >= 2 {
+				use, tag = 2, "strong"
+			}
+			opener.open = append([]string{"<" + tag + ">"}, opener.open...)
+			closer.close = append(closer.close, "</"+tag+">")
+			opener.count -= use
+			closer.count -= use
+
+			for _, t := range tokens[o+1 : c] {
+				if t.emphasis() {
+					t.canOpen, t.canClose = false, false
+				}
+			}
+		}
+
+		if closer.count > 0 && !closer.canOpen {
+			closer.canClose = false
+		}
+	}
+}
+
+func parseLinkTail(s string, i int) (dest, title string, end int, ok bool) {
+	if i >= len(s) || s[i] != '(' {
+		return "", "", 0, false
+	}
+	j := skipWhitespace(s, i+1)
+
+	if j < len(s) && s[j] == '<' {
+		k := j + 1
+		for k < len(s) && s[k] != '>' && s[k] != '\n' && s[k] != '<' {
+			if s[k] == '\\' && k+1 < len(s) {
+				k++
+			}
+			k++
+		}
+		if k >= len(s) || s[k] != '>' {
+			return "", "", 0, false
+		}
+		dest = s[j+1 : k]
+		j = k + 1
+	} else {
+		k, depth := j, 0
+		for k < len(s) {
+			ch := s[k]
+			if ch == '\\' && k+1 < len(s) && isASCIIPunct(s[k+1]) {
+				k += 2
+				continue
+			}
+			if ch <= ' ' {
+				break
+			}
+			if ch == '(' {
+				depth++
+			} else if ch == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+			k++
+		}
+		if depth != 0 {
+			return "", "", 0, false
+		}
+		dest = s[j:k]
+		j = k
+	}
+
+	k := skipWhitespace(s, j)
+	if k > j && k < len(s) && (s[k] == '"' || s[k] == '\'' || s[k] == '(') {
+		closeCh := s[k]
+		if closeCh == '(' {
+			closeCh = ')'
+		}
+		m := k + 1
+		for m < len(s) && s[m] != closeCh {
+			if s[m] == '\\' && m+1 < len(s) {
+				m++
+			}
+			m++
+		}
+		if m >= len(s) {
+			return "", "", 0, false
+		}
+		title = s[k+1 : m]
+		j = skipWhitespace(s, m+1)
+	} else {
+		j = k
+	}
+
+	if j >= len(s) || s[j] != ')' {
+		return "", "", 0, false
+	}
+	return unescapeString(dest), unescapeString(title), j + 1, true
+}
+
+func findCodeClose(s string, i, n int) int {
+	for i < len(s) {
+		k := strings.IndexByte(s[i:], '`')
+		if k < 0 {
+			return -1
+		}
+		start := i + k
+		run := runLength(s, start, '`')
+		if run == n {
+			return start
+		}
+		i = start + run
+	}
+	return -1
+}
+
+func codeContent(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) >= 2 && s[0] == ' ' && s[len(s)-1] == ' ' && strings.Trim(s, " ") != "" {
+		s = s[1 : len(s)-1]
+	}
+	return s
+}
+
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+	return i
+}
+
+func skipWhitespace(s string, i int) int {
+	newline := false
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || (s[i] == '\n' && !newline)) {
+		if s[i] == '\n' {
+			newline = true
+		}
+		i++
+	}
+	return i
+}
+
+func runeBefore(s string, i int) rune {
+	if i == 0 {
+		return ' '
+	}
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return r
+}
+
+func runeAfter(s string, i int) rune {
+	if i >= len(s) {
+		return ' '
+	}
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return r
+}
+
+func isPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func unescapeString(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func escapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '&':
+			if m := rxEntity.FindString(s[i:]); m != "" {
+				b.WriteString(m)
+				i += len(m) - 1
+			} else {
+				b.WriteString("&amp;")
+			}
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '"':
+			b.WriteString("&quot;")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,135 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/geistblitz/boringformat/internal/launder"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type LineRange struct {
+	Start int
+	End   int
+}
+
+func (r LineRange) Union(o LineRange) LineRange {
+	if r.Start == 0 || (o.Start != 0 && o.Start < r.Start) {
+		r.Start = o.Start
+	}
+	if o.End > r.End {
+		r.End = o.End
+	}
+	return r
+}
+
+type Document struct {
+	*launder.Document
+	Lines map[*html.Node]LineRange
+}
+
+func Parse(src []byte) *Document {
+	p := &blockParser{
+		refs:  make(map[string]linkRef),
+		lines: make(map[*html.Node]LineRange),
+		tight: make(map[*html.Node]bool),
+	}
+
+	lines := splitLines(src)
+	root := &html.Node{Type: html.DocumentNode}
+	htmlNode := element(atom.Html)
+	head := element(atom.Head)
+	body := element(atom.Body)
+	root.AppendChild(htmlNode)
+	htmlNode.AppendChild(head)
+	htmlNode.AppendChild(body)
+
+	p.parseBlocks(lines, body)
+	p.runInlines()
+
+	if len(lines) > 0 {
+		all := LineRange{Start: lines[0].num, End: lines[len(lines)-1].num}
+		p.lines[htmlNode] = all
+		p.lines[body] = all
+	}
+
+	return &Document{
+		Document: launder.NewDocumentFromNode(root),
+		Lines:    p.lines,
+	}
+}
+
+func (d *Document) LineRange(n *html.Node) (LineRange, bool) {
+	r, ok := d.Lines[n]
+	return r, ok
+}
+
+func (d *Document) SelectionLines(s *launder.Selection) (LineRange, bool) {
+	var r LineRange
+	found := false
+	for _, n := range s.Nodes {
+		if lr, ok := d.Lines[n]; ok {
+			r = r.Union(lr)
+			found = true
+		}
+	}
+	return r, found
+}
+
+type mdLine struct {
+	text string
+	num  int
+}
+
+func splitLines(src []byte) []mdLine {
+	src = bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))
+	src = bytes.ReplaceAll(src, []byte("\r"), []byte("\n"))
+	src = bytes.ReplaceAll(src, []byte{0}, []byte("�"))
+
+	parts := strings.Split(string(src), "\n")
+	if len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+
+	lines := make([]mdLine, len(parts))
+	for i, l := range parts {
+		lines[i] = mdLine{text: expandTabs(l), num: i + 1}
+	}
+	return lines
+}
+
+func expandTabs(s string) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+
+	var b strings.Builder
+	col := 0
+	for _, r := range s {
+		if r == '\t' {
+			n := 4 - col%4
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
+
+func element(a atom.Atom) *html.Node {
+	return &html.Node{Type: html.ElementNode, DataAtom: a, Data: a.String()}
+}
+
+func elementNamed(name string) *html.Node {
+	return &html.Node{Type: html.ElementNode, DataAtom: atom.Lookup([]byte(name)), Data: name}
+}
+
+func setLines(m map[*html.Node]LineRange, n *html.Node, r LineRange) {
+	m[n] = r
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		setLines(m, c, r)
+	}
+}
@@ -0,0 +1,88 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/geistblitz/boringformat/internal/launder"
+)
+
+const testSource = `# Title
+
+Some *emphasis*, **strong** and ` + "`code`" + `.
+A [link](http://example.com "T") and [ref].
+
+[ref]: /docs "Docs"
+
+> quote
+lazy
+
+- a
+- b
+  - nested
+
+1. one
+
+2. two
+
+` + "```go\nfunc main() {}\n```" + `
+
+| a | b |
+|:--|--:|
+| 1 | 2 |
+`
+
+func TestParse(t *testing.T) {
+	d := Parse([]byte(testSource))
+
+	cases := []struct {
+		sel   string
+		html  string
+		lines LineRange
+	}{
+		{"h1", "<h1>Title</h1>", LineRange{1, 1}},
+		{"body > p", "<p>Some <em>emphasis</em>, <strong>strong</strong> and <code>code</code>.\nA <a href=\"http://example.com\" title=\"T\">link</a> and <a href=\"/docs\" title=\"Docs\">ref</a>.</p>", LineRange{3, 4}},
+		{"blockquote", "<blockquote><p>quote\nlazy</p></blockquote>", LineRange{8, 9}},
+		{"body > ul", "<ul><li>a</li><li>b<ul><li>nested</li></ul></li></ul>", LineRange{11, 13}},
+		{"ol", "<ol><li><p>one</p></li><li><p>two</p></li></ol>", LineRange{15, 17}},
+		{"pre", "<pre><code class=\"language-go\">func main() {}\n</code></pre>", LineRange{19, 21}},
+		{"td[align=right]", "<td align=\"right\">2</td>", LineRange{25, 25}},
+		{"a[title=T]", "<a href=\"http://example.com\" title=\"T\">link</a>", LineRange{3, 4}},
+	}
+
+	for _, c := range cases {
+		s := d.Find(c.sel)
+		if s.Length() != 1 {
+			t.Errorf("%s: expected 1 node, got %d", c.sel, s.Length())
+			continue
+		}
+		if h, _ := launder.OuterHtml(s); h != c.html {
+			t.Errorf("%s: expected %s, got %s", c.sel, c.html, h)
+		}
+		if r, ok := d.LineRange(s.Get(0)); !ok || r != c.lines {
+			t.Errorf("%s: expected lines %v, got %v", c.sel, c.lines, r)
+		}
+	}
+
+	if r, _ := d.SelectionLines(d.Find("h1, blockquote")); r != (LineRange{1, 9}) {
+		t.Errorf("unexpected selection range %v", r)
+	}
+}
+
+func TestTrailingReferenceDefinitions(t *testing.T) {
+	d := Parse([]byte("See [docs].\n\n[docs]: https://example.com/docs\n"))
+	if href, _ := d.Find("a").Attr("href"); href != "https://example.com/docs" {
+		t.Errorf("unexpected link %q", href)
+	}
+	if n := d.Find("p").Length(); n != 1 {
+		t.Errorf("expected 1 paragraph, got %d", n)
+	}
+
+	d = Parse([]byte("[a]: /u\n[b]: /v \"title\"\n"))
+	if n := d.Find("body").Children().Length(); n != 0 {
+		t.Errorf("expected an empty body, got %d children", n)
+	}
+	d = Parse([]byte("[a]: /u\n"))
+	if n := d.Find("body").Children().Length(); n != 0 {
+		t.Errorf("expected an empty body, got %d children", n)
+	}
+}
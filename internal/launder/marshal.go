@@ -0,0 +1,134 @@
+package launder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const DOMVersion = 1
+
+var ErrDOMVersion = errors.New("unsupported DOM encoding version")
+
+type domDocument struct {
+	Version  int      `json:"v"`
+	URL      string   `json:"url,omitempty"`
+	Encoding string   `json:"enc,omitempty"`
+	Root     *domNode `json:"root"`
+}
+
+type domNode struct {
+	Type      html.NodeType `json:"t"`
+	Data      string        `json:"d,omitempty"`
+	Atom      string        `json:"a,omitempty"`
+	Namespace string        `json:"n,omitempty"`
+	Attr      [][]string    `json:"at,omitempty"`
+	Children  []*domNode    `json:"c,omitempty"`
+}
+
+func (d *Document) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.dom())
+}
+
+func (d *Document) UnmarshalJSON(data []byte) error {
+	var dd domDocument
+	if err := json.Unmarshal(data, &dd); err != nil {
+		return err
+	}
+	return d.load(&dd)
+}
+
+func NewDocumentFromJSON(data []byte) (*Document, error) {
+	d := &Document{}
+	if err := d.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Document) dom() *domDocument {
+	dd := &domDocument{Version: DOMVersion, Encoding: d.Encoding, Root: encodeNode(d.rootNode)}
+	if d.Url != nil {
+		dd.URL = d.Url.String()
+	}
+	return dd
+}
+
+func (d *Document) load(dd *domDocument) error {
+	if dd.Version != DOMVersion {
+		return fmt.Errorf("%w: %d", ErrDOMVersion, dd.Version)
+	}
+	if dd.Root == nil {
+		return errors.New("encoded document has no root")
+	}
+	root, err := decodeNode(dd.Root)
+	if err != nil {
+		return err
+	}
+
+	var u *url.URL
+	if dd.URL != "" {
+		if u, err = url.Parse(dd.URL); err != nil {
+			return err
+		}
+	}
+	*d = *newDocument(root, u)
+	d.Selection.document = d
+	d.Encoding = dd.Encoding
+	return nil
+}
+
+func encodeNode(n *html.Node) *domNode {
+	dn := &domNode{Type: n.Type, Data: n.Data, Namespace: n.Namespace}
+	if n.Type == html.ElementNode && n.DataAtom != atom.Lookup([]byte(n.Data)) {
+		dn.Atom = n.DataAtom.String()
+	}
+	for _, a := range n.Attr {
+		if a.Namespace != "" {
+			dn.Attr = append(dn.Attr, []string{a.Key, a.Val, a.Namespace})
+		} else {
+			dn.Attr = append(dn.Attr, []string{a.Key, a.Val})
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		dn.Children = append(dn.Children, encodeNode(c))
+	}
+	return dn
+}
+
+func decodeNode(dn *domNode) (*html.Node, error) {
+	if dn.Type < html.TextNode || dn.Type > html.RawNode {
+		return nil, fmt.Errorf("invalid node type %d", dn.Type)
+	}
+	n := &html.Node{Type: dn.Type, Data: dn.Data, Namespace: dn.Namespace}
+	if dn.Atom != "" {
+		n.DataAtom = atom.Lookup([]byte(dn.Atom))
+	} else if dn.Type == html.ElementNode {
+		n.DataAtom = atom.Lookup([]byte(dn.Data))
+	}
+	for _, a := range dn.Attr {
+		switch len(a) {
+		case 2:
+			n.Attr = append(n.Attr, html.Attribute{Key: a[0], Val: a[1]})
+		case 3:
+			n.Attr = append(n.Attr, html.Attribute{Key: a[0], Val: a[1], Namespace: a[2]})
+		default:
+			return nil, fmt.Errorf("invalid attribute of <%s>: %q", dn.Data, a)
+		}
+	}
+	for _, dc := range dn.Children {
+		if dc == nil {
+			return nil, fmt.Errorf("null child of <%s>", dn.Data)
+		}
+		c, err := decodeNode(dc)
+		if err != nil {
+			return nil, err
+		}
+		n.AppendChild(c)
+	}
+	return n, nil
+}
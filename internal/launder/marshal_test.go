@@ -0,0 +1,144 @@
+package launder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func assertSameTree(t *testing.T, a, b *html.Node) {
+	t.Helper()
+	if a.Type != b.Type || a.Data != b.Data || a.DataAtom != b.DataAtom || a.Namespace != b.Namespace || len(a.Attr) != len(b.Attr) {
+		t.Fatalf("node mismatch: %+v vs %+v", a, b)
+	}
+	for i := range a.Attr {
+		if a.Attr[i] != b.Attr[i] {
+			t.Fatalf("attribute mismatch on <%s>: %+v vs %+v", a.Data, a.Attr[i], b.Attr[i])
+		}
+	}
+	ca, cb := a.FirstChild, b.FirstChild
+	for ; ca != nil && cb != nil; ca, cb = ca.NextSibling, cb.NextSibling {
+		assertSameTree(t, ca, cb)
+	}
+	if ca != nil || cb != nil {
+		t.Fatalf("child count mismatch under <%s>", a.Data)
+	}
+}
+
+func TestDocumentJSON(t *testing.T) {
+	src := `<!DOCTYPE html><html><body><!-- c --><p class="a" data-x="&quot;">text &amp; more</p>` +
+		`<svg viewBox="0 0 1 1"><foreignObject><div>x</div></foreignObject><use xlink:href="#a"/></svg></body></html>`
+	d := loadString(t, src)
+	d.Encoding = "utf-8"
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, err := NewDocumentFromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameTree(t, d.rootNode, d2.rootNode)
+	if d2.Encoding != "utf-8" {
+		t.Errorf("encoding not preserved: %q", d2.Encoding)
+	}
+	if d2.Find("p.a").Text() != "text & more" {
+		t.Errorf("loaded document is not queryable")
+	}
+
+	var msg struct {
+		ID  string    `json:"id"`
+		Doc *Document `json:"doc"`
+	}
+	if err := json.Unmarshal([]byte(`{"id":"1","doc":`+string(data)+`}`), &msg); err != nil {
+		t.Fatal(err)
+	}
+	assertSameTree(t, d.rootNode, msg.Doc.rootNode)
+
+	for _, d := range []*Document{Doc(), DocB(), DocW()} {
+		data, err := d.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d2, err := NewDocumentFromJSON(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameTree(t, d.rootNode, d2.rootNode)
+	}
+}
+
+func TestDocumentJSONVersion(t *testing.T) {
+	_, err := NewDocumentFromJSON([]byte(`{"v":99,"root":{"t":2}}`))
+	if !errors.Is(err, ErrDOMVersion) {
+		t.Errorf("expected version error, got %v", err)
+	}
+	if _, err := NewDocumentFromJSON([]byte(`{"v":1,"root":{"t":2,"c":[{"t":9}]}}`)); err == nil {
+		t.Error("expected invalid node type error")
+	}
+}
+
+func TestDocumentCBOR(t *testing.T) {
+	for _, d := range []*Document{Doc(), DocB(), DocW()} {
+		data, err := d.MarshalCBOR()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d2, err := NewDocumentFromCBOR(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameTree(t, d.rootNode, d2.rootNode)
+
+		js, _ := d.MarshalJSON()
+		if len(data) >= len(js) {
+			t.Errorf("CBOR encoding (%d bytes) is not smaller than JSON (%d bytes)", len(data), len(js))
+		}
+		if _, err := NewDocumentFromCBOR(data[:len(data)/2]); err == nil {
+			t.Error("expected error for truncated CBOR")
+		}
+	}
+
+	d, err := NewDocumentFromReader(strings.NewReader("<p>x</p>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Url, _ = url.Parse("https://example.com/a")
+	data, _ := d.MarshalCBOR()
+	d2, err := NewDocumentFromCBOR(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d2.Url == nil || d2.Url.String() != "https://example.com/a" {
+		t.Errorf("URL not preserved: %v", d2.Url)
+	}
+}
+
+func TestDocumentCBORDepth(t *testing.T) {
+	nested := func(depth int) []byte {
+		data := []byte{0xa2, 0x61, 'v', 0x01, 0x64, 'r', 'o', 'o', 't'}
+		for i := 0; i < depth; i++ {
+			data = append(data, 0xa2, 0x61, 't', 0x03, 0x61, 'c', 0x81)
+		}
+		return append(data, 0xa1, 0x61, 't', 0x01)
+	}
+	if _, err := NewDocumentFromCBOR(nested(100)); err != nil {
+		t.Errorf("unexpected error for moderate nesting: %v", err)
+	}
+	if _, err := NewDocumentFromCBOR(nested(maxCBORDepth + 1)); err != errCBORDepth {
+		t.Errorf("expected depth error for nested nodes, got %v", err)
+	}
+
+	tags := []byte{0xa2, 0x61, 'x'}
+	tags = append(tags, bytes.Repeat([]byte{0xc6}, maxCBORDepth+1)...)
+	tags = append(tags, 0x00, 0x61, 'v', 0x01)
+	if _, err := NewDocumentFromCBOR(tags); err != errCBORDepth {
+		t.Errorf("expected depth error for tag chain, got %v", err)
+	}
+}
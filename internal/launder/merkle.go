@@ -0,0 +1,269 @@
+package launder
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+var rxTokenName = regexp.MustCompile(`(?i)csrf|xsrf|authenticity_token|requestverificationtoken|nonce`)
+
+var urlAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"action": true,
+	"poster": true,
+	"data":   true,
+}
+
+type HashOptions struct {
+	IgnoreAttributes  []string
+	IgnoreQueryParams []string
+	TokenNames        *regexp.Regexp
+	IgnoreComments    bool
+}
+
+func DefaultHashOptions() *HashOptions {
+	return &HashOptions{
+		IgnoreAttributes:  []string{"nonce"},
+		IgnoreQueryParams: []string{"v", "ver", "version", "_", "cb", "cachebust", "t", "ts", "timestamp"},
+		TokenNames:        rxTokenName,
+		IgnoreComments:    true,
+	}
+}
+
+type SubtreeHasher struct {
+	ignoredAttrs  map[string]bool
+	ignoredParams map[string]bool
+	tokenNames    *regexp.Regexp
+	comments      bool
+
+	mu       sync.Mutex
+	trees    map[*html.Node]map[*html.Node]uint64
+	versions map[*Document]uint64
+}
+
+func NewSubtreeHasher(opts *HashOptions) *SubtreeHasher {
+	if opts == nil {
+		opts = DefaultHashOptions()
+	}
+
+	h := &SubtreeHasher{
+		ignoredAttrs:  make(map[string]bool),
+		ignoredParams: make(map[string]bool),
+		tokenNames:    opts.TokenNames,
+		comments:      opts.IgnoreComments,
+		trees:         make(map[*html.Node]map[*html.Node]uint64),
+		versions:      make(map[*Document]uint64),
+	}
+	for _, a := range opts.IgnoreAttributes {
+		h.ignoredAttrs[strings.ToLower(a)] = true
+	}
+	for _, p := range opts.IgnoreQueryParams {
+		h.ignoredParams[p] = true
+	}
+	return h
+}
+
+func (s *Selection) SubtreeHash() uint64 {
+	if len(s.Nodes) == 0 {
+		return 0
+	}
+	if s.document == nil {
+		return NewSubtreeHasher(nil).Hash(s.Nodes[0])
+	}
+	h := s.document.subtreeHasher()
+	h.sync(s.document)
+	return h.Hash(s.Nodes[0])
+}
+
+func (d *Document) subtreeHasher() *SubtreeHasher {
+	if d.cache == nil {
+		return NewSubtreeHasher(nil)
+	}
+	d.cache.mu.Lock()
+	defer d.cache.mu.Unlock()
+	if d.cache.hasher == nil {
+		d.cache.hasher = NewSubtreeHasher(nil)
+	}
+	return d.cache.hasher
+}
+
+func (s *Selection) SubtreeHashes(h *SubtreeHasher) []uint64 {
+	if h == nil {
+		h = NewSubtreeHasher(nil)
+	}
+	h.sync(s.document)
+	result := make([]uint64, len(s.Nodes))
+	for i, n := range s.Nodes {
+		result[i] = h.Hash(n)
+	}
+	return result
+}
+
+func (h *SubtreeHasher) Hash(n *html.Node) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	root := treeRoot(n)
+	t, ok := h.trees[root]
+	if !ok {
+		t = make(map[*html.Node]uint64)
+		h.trees[root] = t
+	}
+	return h.hash(t, n)
+}
+
+func (h *SubtreeHasher) HashString(n *html.Node) string {
+	return fmt.Sprintf("%016x", h.Hash(n))
+}
+
+func (h *SubtreeHasher) Cached(n *html.Node) (uint64, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	v, ok := h.trees[treeRoot(n)][n]
+	return v, ok
+}
+
+func (h *SubtreeHasher) Invalidate(n *html.Node) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t := h.trees[treeRoot(n)]
+	for ; n != nil; n = n.Parent {
+		delete(t, n)
+	}
+}
+
+func (h *SubtreeHasher) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.trees = make(map[*html.Node]map[*html.Node]uint64)
+	h.versions = make(map[*Document]uint64)
+}
+
+func (h *SubtreeHasher) sync(d *Document) {
+	if d == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if v, ok := h.versions[d]; ok && v != d.version {
+		delete(h.trees, d.rootNode)
+	}
+	h.versions[d] = d.version
+}
+
+func treeRoot(n *html.Node) *html.Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+func (h *SubtreeHasher) hash(t map[*html.Node]uint64, n *html.Node) uint64 {
+	if v, ok := t[n]; ok {
+		return v
+	}
+
+	w := fnv.New64a()
+	var buf [8]byte
+	writeField := func(s string) {
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(s)))
+		w.Write(buf[:])
+		w.Write([]byte(s))
+	}
+
+	writeField(strconv.Itoa(int(n.Type)))
+	writeField(n.Namespace)
+	writeField(n.Data)
+
+	attrs := h.attributes(n)
+	binary.LittleEndian.PutUint64(buf[:], uint64(len(attrs)))
+	w.Write(buf[:])
+	for _, a := range attrs {
+		writeField(a.Namespace)
+		writeField(a.Key)
+		writeField(a.Val)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if h.comments && c.Type == html.CommentNode {
+			continue
+		}
+		binary.LittleEndian.PutUint64(buf[:], h.hash(t, c))
+		w.Write(buf[:])
+	}
+
+	v := w.Sum64()
+	t[n] = v
+	return v
+}
+
+func (h *SubtreeHasher) attributes(n *html.Node) []html.Attribute {
+	if len(n.Attr) == 0 {
+		return nil
+	}
+
+	token := false
+	if h.tokenNames != nil {
+		for _, a := range n.Attr {
+			if (a.Key == "name" || a.Key == "id") && h.tokenNames.MatchString(a.Val) {
+				token = true
+				break
+			}
+		}
+	}
+
+	attrs := make([]html.Attribute, 0, len(n.Attr))
+	for _, a := range n.Attr {
+		if h.ignoredAttrs[a.Key] {
+			continue
+		}
+		if token && (a.Key == "value" || a.Key == "content") {
+			continue
+		}
+		if urlAttributes[a.Key] && len(h.ignoredParams) > 0 {
+			a.Val = h.stripQuery(a.Val)
+		}
+		attrs = append(attrs, a)
+	}
+
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].Namespace != attrs[j].Namespace {
+			return attrs[i].Namespace < attrs[j].Namespace
+		}
+		return attrs[i].Key < attrs[j].Key
+	})
+	return attrs
+}
+
+func (h *SubtreeHasher) stripQuery(v string) string {
+	if !strings.Contains(v, "?") {
+		return v
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return v
+	}
+
+	q := u.Query()
+	changed := false
+	for k := range q {
+		if h.ignoredParams[k] {
+			q.Del(k)
+			changed = true
+		}
+	}
+	if !changed {
+		return v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,121 @@
+package launder
+
+import "testing"
+
+func subtreeHash(t *testing.T, h *SubtreeHasher, page string) uint64 {
+	return loadString(t, page).Find("body").SubtreeHashes(h)[0]
+}
+
+func TestSubtreeHashNormalization(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"ignored attribute", `<p nonce="a1">x</p>`, `<p nonce="b2">x</p>`, true},
+		{"attribute order", `<p id="a" class="b">x</p>`, `<p class="b" id="a">x</p>`, true},
+		{"other attribute", `<p title="a">x</p>`, `<p title="b">x</p>`, false},
+		{"token by name", `<input name="csrf_token" value="abc">`, `<input name="csrf_token" value="xyz">`, true},
+		{"token by id", `<meta id="authenticity_token" content="abc">`, `<meta id="authenticity_token" content="xyz">`, true},
+		{"plain value", `<input name="q" value="abc">`, `<input name="q" value="xyz">`, false},
+		{"ignored query param", `<script src="/app.js?v=1"></script>`, `<script src="/app.js?v=2"></script>`, true},
+		{"kept query param", `<a href="/item?id=1&ts=5">x</a>`, `<a href="/item?id=2&ts=5">x</a>`, false},
+		{"non-hashed URL attribute", `<blockquote cite="/q?v=1">x</blockquote>`, `<blockquote cite="/q?v=2">x</blockquote>`, false},
+		{"mixed query params", `<a href="/item?id=1&ts=5">x</a>`, `<a href="/item?id=1&ts=9">x</a>`, true},
+		{"comment", `<p>x<!-- build 1 --></p>`, `<p>x<!-- build 2 --></p>`, true},
+		{"text", `<p>x</p>`, `<p>y</p>`, false},
+	}
+	for _, c := range cases {
+		h := NewSubtreeHasher(nil)
+		a, b := subtreeHash(t, h, c.a), subtreeHash(t, h, c.b)
+		if (a == b) != c.same {
+			t.Errorf("%s: expected same=%v, got %016x and %016x", c.name, c.same, a, b)
+		}
+	}
+
+	opts := DefaultHashOptions()
+	opts.IgnoreComments = false
+	h := NewSubtreeHasher(opts)
+	if subtreeHash(t, h, `<p>x<!-- a --></p>`) == subtreeHash(t, h, `<p>x<!-- b --></p>`) {
+		t.Error("comments must be hashed when not ignored")
+	}
+	opts.IgnoreQueryParams = nil
+	h = NewSubtreeHasher(opts)
+	if subtreeHash(t, h, `<img src="a.png?v=1">`) == subtreeHash(t, h, `<img src="a.png?v=2">`) {
+		t.Error("query params must be hashed when not ignored")
+	}
+}
+
+func TestSubtreeHashInvalidation(t *testing.T) {
+	d := loadString(t, `<div id="a"><p>one</p></div><div id="b"><p>two</p></div>`)
+	h := NewSubtreeHasher(nil)
+	before := d.Find("div").SubtreeHashes(h)
+	if _, ok := h.Cached(d.Find("#a p").Nodes[0]); !ok {
+		t.Fatal("expected descendants to be cached")
+	}
+
+	d.Find("#a p").SetText("changed")
+	after := d.Find("div").SubtreeHashes(h)
+	if after[0] == before[0] {
+		t.Error("stale hash after a Selection mutation")
+	}
+	if after[1] != before[1] {
+		t.Error("unchanged subtree must hash the same")
+	}
+	if fresh := d.Find("div").SubtreeHashes(NewSubtreeHasher(nil)); fresh[0] != after[0] {
+		t.Error("hash differs from a fresh hasher")
+	}
+
+	target := loadString(t, `<div id="a"><p>patched</p></div><div id="b"><p>two</p></div>`)
+	if err := Diff(d, target).Apply(d); err != nil {
+		t.Fatal(err)
+	}
+	got := d.Find("div").SubtreeHashes(h)
+	want := target.Find("div").SubtreeHashes(NewSubtreeHasher(nil))
+	if got[0] != want[0] {
+		t.Error("stale hash after Patch.Apply")
+	}
+
+	n := d.Find("#b p").Nodes[0]
+	old := h.Hash(n)
+	n.FirstChild.Data = "direct"
+	if h.Hash(n) != old {
+		t.Error("direct node edits are not expected to be detected")
+	}
+	h.Invalidate(n.FirstChild)
+	if h.Hash(n) == old {
+		t.Error("expected a new hash after Invalidate")
+	}
+}
+
+func TestSubtreeHashCache(t *testing.T) {
+	d := loadString(t, `<div id="a"><p>one</p></div><div id="b"><p>two</p></div>`)
+	first := d.Find("#a").SubtreeHash()
+	h := d.subtreeHasher()
+	if h != d.subtreeHasher() {
+		t.Fatal("expected the document to keep one hasher")
+	}
+	if v, ok := h.Cached(d.Find("#a p").Nodes[0]); !ok || v == 0 {
+		t.Error("expected SubtreeHash to fill the document's hasher")
+	}
+	if d.Find("#a").SubtreeHash() != first {
+		t.Error("hash changed without a mutation")
+	}
+	d.Find("#a p").SetText("changed")
+	if d.Find("#a").SubtreeHash() == first {
+		t.Error("stale hash after a Selection mutation")
+	}
+
+	other := loadString(t, `<div id="c"><p>three</p></div>`)
+	shared := NewSubtreeHasher(nil)
+	d.Find("div").SubtreeHashes(shared)
+	other.Find("div").SubtreeHashes(shared)
+	d.Find("#b p").SetText("edited")
+	d.Find("#b").SubtreeHashes(shared)
+	if _, ok := shared.Cached(other.Find("#c p").Nodes[0]); !ok {
+		t.Error("editing one document must not drop another document's hashes")
+	}
+	if _, ok := shared.Cached(d.Find("#a p").Nodes[0]); ok {
+		t.Error("expected the edited document's hashes to be dropped")
+	}
+}
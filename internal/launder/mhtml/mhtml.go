@@ -0,0 +1,183 @@
+package mhtml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"github.com/geistblitz/boringformat/internal/launder"
+	"golang.org/x/net/html"
+)
+
+var ErrNoRoot = errors.New("mhtml: archive has no HTML part")
+
+type Part struct {
+	Header      textproto.MIMEHeader
+	ContentType string
+	MIMEType    string
+	Location    string
+	ContentID   string
+	Data        []byte
+}
+
+type Archive struct {
+	Header   textproto.MIMEHeader
+	Root     *Part
+	Parts    []*Part
+	Document *launder.Document
+
+	byLocation map[string]*Part
+	byID       map[string]*Part
+}
+
+func Parse(r io.Reader) (*Archive, error) {
+	br := bufio.NewReader(r)
+	header, err := textproto.NewReader(br).ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("mhtml: %w", err)
+	}
+
+	a := &Archive{
+		Header:     header,
+		byLocation: make(map[string]*Part),
+		byID:       make(map[string]*Part),
+	}
+
+	mt, params, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	start := ""
+	if strings.HasPrefix(mt, "multipart/") {
+		mr := multipart.NewReader(br, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("mhtml: %w", err)
+			}
+			part, err := newPart(textproto.MIMEHeader(p.Header), p)
+			if err != nil {
+				return nil, err
+			}
+			a.add(part)
+		}
+		start = trimID(params["start"])
+	} else {
+		part, err := newPart(header, br)
+		if err != nil {
+			return nil, err
+		}
+		a.add(part)
+	}
+
+	if start != "" {
+		a.Root = a.byID[start]
+	}
+	for _, p := range a.Parts {
+		if a.Root != nil {
+			break
+		}
+		if p.MIMEType == "text/html" || p.MIMEType == "application/xhtml+xml" {
+			a.Root = p
+		}
+	}
+	if a.Root == nil {
+		return nil, ErrNoRoot
+	}
+
+	doc, err := launder.NewDocumentFromReaderWithContentType(bytes.NewReader(a.Root.Data), a.Root.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(a.Root.Location); err == nil && a.Root.Location != "" {
+		doc.Url = u
+	}
+	a.Document = doc
+	return a, nil
+}
+
+func newPart(header textproto.MIMEHeader, body io.Reader) (*Part, error) {
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("mhtml: %s: %w", header.Get("Content-Location"), err)
+	}
+
+	p := &Part{
+		Header:      header,
+		ContentType: header.Get("Content-Type"),
+		Location:    strings.TrimSpace(header.Get("Content-Location")),
+		ContentID:   trimID(header.Get("Content-ID")),
+		Data:        data,
+	}
+	p.MIMEType, _, _ = mime.ParseMediaType(p.ContentType)
+	return p, nil
+}
+
+func (a *Archive) add(p *Part) {
+	a.Parts = append(a.Parts, p)
+	if p.Location != "" {
+		if _, ok := a.byLocation[p.Location]; !ok {
+			a.byLocation[p.Location] = p
+		}
+	}
+	if p.ContentID != "" {
+		a.byID[p.ContentID] = p
+	}
+}
+
+func (a *Archive) Lookup(ref string) *Part {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil
+	}
+	if strings.HasPrefix(strings.ToLower(ref), "cid:") {
+		id, err := url.PathUnescape(ref[4:])
+		if err != nil {
+			id = ref[4:]
+		}
+		return a.byID[trimID(id)]
+	}
+	if p, ok := a.byLocation[ref]; ok {
+		return p
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil
+	}
+	if a.Document.Url != nil {
+		u = a.Document.Url.ResolveReference(u)
+	}
+	u.Fragment = ""
+	return a.byLocation[u.String()]
+}
+
+func (a *Archive) ResolveReferences() *Archive {
+	a.Document.RewriteURLs(func(n *html.Node, attr, ref string) string {
+		if p := a.Lookup(ref); p != nil && p.Location != "" {
+			return p.Location
+		}
+		return ref
+	})
+	return a
+}
+
+func trimID(id string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(id), "<"), ">")
+}
@@ -0,0 +1,77 @@
+package mhtml
+
+import (
+	"strings"
+	"testing"
+)
+
+const testArchive = "From: <Saved by Blink>\r\n" +
+	"Snapshot-Content-Location: https://example.com/articles/page.html\r\n" +
+	"Subject: Test page\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/related;\r\n" +
+	"\ttype=\"text/html\";\r\n" +
+	"\tboundary=\"----MultipartBoundary--abc\"\r\n" +
+	"\r\n" +
+	"------MultipartBoundary--abc\r\n" +
+	"Content-Type: text/html\r\n" +
+	"Content-ID: <frame-1@mhtml.blink>\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"Content-Location: https://example.com/articles/page.html\r\n" +
+	"\r\n" +
+	"<html><head><meta http-equiv=3D\"Content-Type\" content=3D\"text/html; charset=3DUTF-8\">=\r\n" +
+	"<link rel=3D\"stylesheet\" href=3D\"../css/site.css\"></head><body><p>Caf=C3=A9 =\r\n" +
+	"au lait</p><img src=3D\"cid:img-1@mhtml.blink\"><img src=3D\"logo.png\"></body></html>\r\n" +
+	"------MultipartBoundary--abc\r\n" +
+	"Content-Type: text/css\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"Content-Location: https://example.com/css/site.css\r\n" +
+	"\r\n" +
+	"p { color: red; }\r\n" +
+	"------MultipartBoundary--abc\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"Content-ID: <img-1@mhtml.blink>\r\n" +
+	"Content-Location: https://example.com/articles/photo.png\r\n" +
+	"\r\n" +
+	"iVBORw0K\r\n" +
+	"Ggo=\r\n" +
+	"------MultipartBoundary--abc\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"Content-Location: https://example.com/articles/logo.png\r\n" +
+	"\r\n" +
+	"AAEC\r\n" +
+	"------MultipartBoundary--abc--\r\n"
+
+func TestParse(t *testing.T) {
+	a, err := Parse(strings.NewReader(testArchive))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.Parts) != 4 || a.Root != a.Parts[0] {
+		t.Fatalf("unexpected parts %d, root %v", len(a.Parts), a.Root)
+	}
+	if txt := a.Document.Find("p").Text(); txt != "Café au lait" {
+		t.Errorf("unexpected decoded text %q", txt)
+	}
+	if a.Document.Url.String() != "https://example.com/articles/page.html" {
+		t.Errorf("unexpected document URL %s", a.Document.Url)
+	}
+
+	if p := a.Lookup("cid:img-1@mhtml.blink"); p == nil || string(p.Data) != "\x89PNG\r\n\x1a\n" {
+		t.Errorf("cid lookup failed: %+v", p)
+	}
+	if p := a.Lookup("../css/site.css"); p == nil || p.MIMEType != "text/css" {
+		t.Errorf("relative lookup failed: %+v", p)
+	}
+	if p := a.Lookup("logo.png"); p == nil || string(p.Data) != "\x00\x01\x02" {
+		t.Errorf("base64 decode failed: %+v", p)
+	}
+
+	a.ResolveReferences()
+	if src, _ := a.Document.Find("img").First().Attr("src"); src != "https://example.com/articles/photo.png" {
+		t.Errorf("cid reference not resolved: %q", src)
+	}
+}
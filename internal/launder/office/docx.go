@@ -0,0 +1,463 @@
+package office
+
+import (
+	"archive/zip"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/geistblitz/boringformat/internal/launder"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const nsRelationships = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+var rxHeadingStyle = regexp.MustCompile(`(?i)^heading\s*([1-9])$`)
+
+func OpenDOCX(name string) (*launder.Document, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return readDOCX(&zr.Reader)
+}
+
+func ReadDOCX(r io.ReaderAt, size int64) (*launder.Document, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return readDOCX(zr)
+}
+
+type docxRelationship struct {
+	target   string
+	external bool
+}
+
+type docxReader struct {
+	*builder
+	archive   *archive
+	rels      map[string]docxRelationship
+	headings  map[string]int
+	numbering map[string]map[int]bool
+	noteXML   map[string]*xmlNode
+}
+
+func readDOCX(zr *zip.Reader) (*launder.Document, error) {
+	r := &docxReader{
+		archive:   newArchive(zr),
+		rels:      make(map[string]docxRelationship),
+		headings:  make(map[string]int),
+		numbering: make(map[string]map[int]bool),
+		noteXML:   make(map[string]*xmlNode),
+	}
+
+	title := ""
+	if r.archive.has("docProps/core.xml") {
+		if core, err := r.archive.parse("docProps/core.xml"); err == nil {
+			if t := core.find("title"); t != nil {
+				title = strings.TrimSpace(t.text())
+			}
+		}
+	}
+	r.builder = newBuilder(title)
+
+	if err := r.loadRelationships(); err != nil {
+		return nil, err
+	}
+	r.loadStyles()
+	r.loadNumbering()
+	r.loadNotes("word/footnotes.xml", "footnote")
+	r.loadNotes("word/endnotes.xml", "endnote")
+
+	doc, err := r.archive.parse("word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	if body := doc.find("body"); body != nil {
+		r.blocks(body)
+	}
+	return r.document(), nil
+}
+
+func (r *docxReader) loadRelationships() error {
+	name := "word/_rels/document.xml.rels"
+	if !r.archive.has(name) {
+		return nil
+	}
+	rels, err := r.archive.parse(name)
+	if err != nil {
+		return err
+	}
+	for _, rel := range rels.findAll("Relationship") {
+		target := rel.attr("Target")
+		external := rel.attr("TargetMode") == "External"
+		switch {
+		case external:
+		case strings.HasPrefix(target, "/"):
+			target = strings.TrimPrefix(path.Clean(target), "/")
+		default:
+			target = path.Join("word", target)
+		}
+		r.rels[rel.attr("Id")] = docxRelationship{target: target, external: external}
+	}
+	return nil
+}
+
+func (r *docxReader) loadStyles() {
+	if !r.archive.has("word/styles.xml") {
+		return
+	}
+	styles, err := r.archive.parse("word/styles.xml")
+	if err != nil {
+		return
+	}
+	for _, s := range styles.findAll("style") {
+		id := s.attr("styleId")
+		name := ""
+		if n := s.child("name"); n != nil {
+			name = n.attr("val")
+		}
+		switch {
+		case strings.EqualFold(name, "title"):
+			r.headings[id] = 1
+		case rxHeadingStyle.MatchString(name):
+			r.headings[id], _ = strconv.Atoi(rxHeadingStyle.FindStringSubmatch(name)[1])
+		default:
+			if lvl := s.find("outlineLvl"); lvl != nil {
+				if n, err := strconv.Atoi(lvl.attr("val")); err == nil && n < 9 {
+					r.headings[id] = n + 1
+				}
+			}
+		}
+	}
+}
+
+func (r *docxReader) loadNumbering() {
+	if !r.archive.has("word/numbering.xml") {
+		return
+	}
+	numbering, err := r.archive.parse("word/numbering.xml")
+	if err != nil {
+		return
+	}
+
+	abstract := make(map[string]map[int]bool)
+	for _, an := range numbering.findAll("abstractNum") {
+		levels := make(map[int]bool)
+		for _, lvl := range an.findAll("lvl") {
+			ilvl, _ := strconv.Atoi(lvl.attr("ilvl"))
+			ordered := false
+			if f := lvl.child("numFmt"); f != nil {
+				ordered = f.attr("val") != "bullet" && f.attr("val") != "none"
+			}
+			levels[ilvl] = ordered
+		}
+		abstract[an.attr("abstractNumId")] = levels
+	}
+	for _, num := range numbering.findAll("num") {
+		if a := num.child("abstractNumId"); a != nil {
+			r.numbering[num.attr("numId")] = abstract[a.attr("val")]
+		}
+	}
+}
+
+func (r *docxReader) loadNotes(name, element string) {
+	if !r.archive.has(name) {
+		return
+	}
+	notes, err := r.archive.parse(name)
+	if err != nil {
+		return
+	}
+	for _, n := range notes.findAll(element) {
+		r.noteXML[element+":"+n.attr("id")] = n
+	}
+}
+
+func (r *docxReader) blocks(parent *xmlNode) {
+	for _, c := range parent.Children {
+		switch c.Name.Local {
+		case "p":
+			r.paragraph(c)
+		case "tbl":
+			r.block(r.table(c))
+		case "sdt":
+			if content := c.child("sdtContent"); content != nil {
+				r.blocks(content)
+			}
+		}
+	}
+}
+
+func (r *docxReader) paragraph(p *xmlNode) {
+	level, numID, ilvl := 0, "", 0
+	if ppr := p.child("pPr"); ppr != nil {
+		if s := ppr.child("pStyle"); s != nil {
+			level = r.headings[s.attr("val")]
+		}
+		if o := ppr.child("outlineLvl"); o != nil && level == 0 {
+			if n, err := strconv.Atoi(o.attr("val")); err == nil && n < 9 {
+				level = n + 1
+			}
+		}
+		if num := ppr.child("numPr"); num != nil {
+			if id := num.child("numId"); id != nil {
+				numID = id.attr("val")
+			}
+			if l := num.child("ilvl"); l != nil {
+				ilvl, _ = strconv.Atoi(l.attr("val"))
+				ilvl = clampListLevel(ilvl)
+			}
+		}
+	}
+
+	switch {
+	case level > 0:
+		h := heading(level)
+		r.inlines(h, p)
+		r.block(h)
+	case numID != "" && numID != "0":
+		li := r.listItem(ilvl, r.numbering[numID][ilvl])
+		r.inlines(li, p)
+	default:
+		para := element(atom.P)
+		r.inlines(para, p)
+		if para.FirstChild != nil {
+			r.block(para)
+		} else {
+			r.lists = nil
+		}
+	}
+}
+
+func (r *docxReader) inlines(parent *html.Node, n *xmlNode) {
+	for _, c := range n.Children {
+		switch c.Name.Local {
+		case "r":
+			r.run(parent, c)
+		case "hyperlink":
+			a := element(atom.A)
+			if rel, ok := r.rels[c.attrNS(nsRelationships, "id")]; ok {
+				a.Attr = append(a.Attr, html.Attribute{Key: "href", Val: rel.target})
+			} else if anchor := c.attr("anchor"); anchor != "" {
+				a.Attr = append(a.Attr, html.Attribute{Key: "href", Val: "#" + anchor})
+			}
+			parent.AppendChild(a)
+			r.inlines(a, c)
+		case "bookmarkStart":
+			if name := c.attr("name"); name != "" && !strings.HasPrefix(name, "_GoBack") {
+				a := element(atom.A)
+				a.Attr = []html.Attribute{{Key: "id", Val: name}}
+				parent.AppendChild(a)
+			}
+		case "ins", "smartTag", "fldSimple", "customXml":
+			r.inlines(parent, c)
+		case "sdt":
+			if content := c.child("sdtContent"); content != nil {
+				r.inlines(parent, content)
+			}
+		}
+	}
+}
+
+func (r *docxReader) run(parent *html.Node, run *xmlNode) {
+	var f runFormat
+	if rpr := run.child("rPr"); rpr != nil {
+		f.bold = toggle(rpr.child("b"))
+		f.italic = toggle(rpr.child("i"))
+		f.strike = toggle(rpr.child("strike"))
+		if u := rpr.child("u"); u != nil && u.attr("val") != "none" {
+			f.underline = true
+		}
+		if v := rpr.child("vertAlign"); v != nil {
+			f.sup = v.attr("val") == "superscript"
+			f.sub = v.attr("val") == "subscript"
+		}
+	}
+
+	target := parent
+	ensure := func() *html.Node {
+		if target == parent {
+			target = f.apply(parent)
+		}
+		return target
+	}
+
+	for _, c := range run.Children {
+		switch c.Name.Local {
+		case "t":
+			appendText(ensure(), c.text())
+		case "tab":
+			appendText(ensure(), "\t")
+		case "br", "cr":
+			if c.attr("type") != "page" {
+				ensure().AppendChild(element(atom.Br))
+			}
+		case "noBreakHyphen":
+			appendText(ensure(), "‑")
+		case "drawing", "pict", "object":
+			if img := r.image(c); img != nil {
+				ensure().AppendChild(img)
+			}
+		case "footnoteReference", "endnoteReference":
+			kind := strings.TrimSuffix(c.Name.Local, "Reference")
+			id := c.attr("id")
+			sup, li := r.noteReference(kind + "-" + id)
+			parent.AppendChild(sup)
+			if note, ok := r.noteXML[kind+":"+id]; ok {
+				for _, p := range note.findAll("p") {
+					r.inlines(li, p)
+					appendText(li, " ")
+				}
+				if last := li.LastChild; last != nil && last.Type == html.TextNode {
+					last.Data = strings.TrimRight(last.Data, " ")
+				}
+			}
+		}
+	}
+}
+
+func (r *docxReader) image(n *xmlNode) *html.Node {
+	id := ""
+	if blip := n.find("blip"); blip != nil {
+		id = blip.attrNS(nsRelationships, "embed")
+		if id == "" {
+			id = blip.attrNS(nsRelationships, "link")
+		}
+	} else if data := n.find("imagedata"); data != nil {
+		id = data.attrNS(nsRelationships, "id")
+	}
+	rel, ok := r.rels[id]
+	if !ok {
+		return nil
+	}
+
+	img := element(atom.Img)
+	img.Attr = []html.Attribute{{Key: "src", Val: rel.target}}
+	if pr := n.find("docPr"); pr != nil {
+		alt := pr.attr("descr")
+		if alt == "" {
+			alt = pr.attr("title")
+		}
+		img.Attr = append(img.Attr, html.Attribute{Key: "alt", Val: alt})
+	}
+	return img
+}
+
+func (r *docxReader) table(tbl *xmlNode) *html.Node {
+	table := element(atom.Table)
+	vmerge := make(map[int]*html.Node)
+
+	for _, tr := range tbl.Children {
+		if tr.Name.Local != "tr" {
+			continue
+		}
+		header := false
+		if trPr := tr.child("trPr"); trPr != nil && toggle(trPr.child("tblHeader")) {
+			header = true
+		}
+
+		row := element(atom.Tr)
+		col := 0
+		for _, tc := range tr.Children {
+			if tc.Name.Local != "tc" {
+				continue
+			}
+			span, merge := 1, ""
+			if pr := tc.child("tcPr"); pr != nil {
+				if g := pr.child("gridSpan"); g != nil {
+					span, _ = strconv.Atoi(g.attr("val"))
+				}
+				if v := pr.child("vMerge"); v != nil {
+					merge = v.attr("val")
+					if merge == "" {
+						merge = "continue"
+					}
+				}
+			}
+			if span < 1 {
+				span = 1
+			}
+
+			if merge == "continue" {
+				if above, ok := vmerge[col]; ok {
+					incrementSpan(above, "rowspan")
+					col += span
+					continue
+				}
+			}
+
+			cell := element(atom.Td)
+			if header {
+				cell = element(atom.Th)
+			}
+			if span > 1 {
+				cell.Attr = append(cell.Attr, html.Attribute{Key: "colspan", Val: strconv.Itoa(span)})
+			}
+			if merge == "restart" {
+				vmerge[col] = cell
+			} else {
+				delete(vmerge, col)
+			}
+			r.cell(cell, tc)
+			row.AppendChild(cell)
+			col += span
+		}
+		table.AppendChild(row)
+	}
+
+	return table
+}
+
+func (r *docxReader) cell(cell *html.Node, tc *xmlNode) {
+	var paras []*xmlNode
+	for _, c := range tc.Children {
+		if c.Name.Local == "p" || c.Name.Local == "tbl" {
+			paras = append(paras, c)
+		}
+	}
+
+	if len(paras) == 1 && paras[0].Name.Local == "p" {
+		r.inlines(cell, paras[0])
+		return
+	}
+	for _, c := range paras {
+		if c.Name.Local == "tbl" {
+			cell.AppendChild(r.table(c))
+			continue
+		}
+		p := element(atom.P)
+		r.inlines(p, c)
+		if p.FirstChild != nil {
+			cell.AppendChild(p)
+		}
+	}
+}
+
+func incrementSpan(n *html.Node, key string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			v, _ := strconv.Atoi(a.Val)
+			n.Attr[i].Val = strconv.Itoa(v + 1)
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: "2"})
+}
+
+func toggle(n *xmlNode) bool {
+	if n == nil {
+		return false
+	}
+	switch n.attr("val") {
+	case "0", "false", "off", "none":
+		return false
+	}
+	return true
+}
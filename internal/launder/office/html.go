@@ -0,0 +1,177 @@
+package office
+
+import (
+	"strconv"
+
+	"github.com/geistblitz/boringformat/internal/launder"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type builder struct {
+	root *html.Node
+	body *html.Node
+
+	lists     []listLevel
+	notes     *html.Node
+	noteCount int
+}
+
+type listLevel struct {
+	list    *html.Node
+	ordered bool
+}
+
+func newBuilder(title string) *builder {
+	b := &builder{root: &html.Node{Type: html.DocumentNode}}
+	htmlNode := element(atom.Html)
+	head := element(atom.Head)
+	b.body = element(atom.Body)
+	b.root.AppendChild(htmlNode)
+	htmlNode.AppendChild(head)
+	htmlNode.AppendChild(b.body)
+	if title != "" {
+		t := element(atom.Title)
+		t.AppendChild(text(title))
+		head.AppendChild(t)
+	}
+	return b
+}
+
+func (b *builder) document() *launder.Document {
+	if b.notes != nil {
+		section := element(atom.Section)
+		section.Attr = []html.Attribute{{Key: "class", Val: "footnotes"}}
+		section.AppendChild(b.notes)
+		b.body.AppendChild(section)
+	}
+	return launder.NewDocumentFromNode(b.root)
+}
+
+func (b *builder) block(n *html.Node) {
+	b.lists = nil
+	b.body.AppendChild(n)
+}
+
+const maxListLevel = 8
+
+func clampListLevel(level int) int {
+	switch {
+	case level < 0:
+		return 0
+	case level > maxListLevel:
+		return maxListLevel
+	}
+	return level
+}
+
+func (b *builder) listItem(level int, ordered bool) *html.Node {
+	level = clampListLevel(level)
+	for len(b.lists) > level+1 {
+		b.lists = b.lists[:len(b.lists)-1]
+	}
+	if len(b.lists) == level+1 && b.lists[level].ordered != ordered {
+		b.lists = b.lists[:level]
+	}
+
+	for len(b.lists) < level+1 {
+		list := element(atom.Ul)
+		if ordered {
+			list = element(atom.Ol)
+		}
+		if len(b.lists) == 0 {
+			b.body.AppendChild(list)
+		} else {
+			parent := b.lists[len(b.lists)-1].list
+			li := parent.LastChild
+			if li == nil {
+				li = element(atom.Li)
+				parent.AppendChild(li)
+			}
+			li.AppendChild(list)
+		}
+		b.lists = append(b.lists, listLevel{list: list, ordered: ordered})
+	}
+
+	li := element(atom.Li)
+	b.lists[level].list.AppendChild(li)
+	return li
+}
+
+func (b *builder) noteReference(id string) (*html.Node, *html.Node) {
+	if b.notes == nil {
+		b.notes = element(atom.Ol)
+	}
+	b.noteCount++
+	n := strconv.Itoa(b.noteCount)
+
+	sup := element(atom.Sup)
+	a := element(atom.A)
+	a.Attr = []html.Attribute{{Key: "href", Val: "#fn-" + id}, {Key: "id", Val: "fnref-" + id}}
+	a.AppendChild(text(n))
+	sup.AppendChild(a)
+
+	li := element(atom.Li)
+	li.Attr = []html.Attribute{{Key: "id", Val: "fn-" + id}}
+	b.notes.AppendChild(li)
+	return sup, li
+}
+
+func element(a atom.Atom) *html.Node {
+	return &html.Node{Type: html.ElementNode, DataAtom: a, Data: a.String()}
+}
+
+func heading(level int) *html.Node {
+	if level < 1 {
+		level = 1
+	}
+	if level > 6 {
+		level = 6
+	}
+	return element([]atom.Atom{atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6}[level-1])
+}
+
+func text(s string) *html.Node {
+	return &html.Node{Type: html.TextNode, Data: s}
+}
+
+func appendText(parent *html.Node, s string) {
+	if s == "" {
+		return
+	}
+	if last := parent.LastChild; last != nil && last.Type == html.TextNode {
+		last.Data += s
+		return
+	}
+	parent.AppendChild(text(s))
+}
+
+type runFormat struct {
+	bold      bool
+	italic    bool
+	underline bool
+	strike    bool
+	sup       bool
+	sub       bool
+}
+
+func (f runFormat) apply(parent *html.Node) *html.Node {
+	for _, t := range []struct {
+		on bool
+		a  atom.Atom
+	}{
+		{f.bold, atom.Strong},
+		{f.italic, atom.Em},
+		{f.underline, atom.U},
+		{f.strike, atom.S},
+		{f.sup, atom.Sup},
+		{f.sub, atom.Sub},
+	} {
+		if t.on {
+			n := element(t.a)
+			parent.AppendChild(n)
+			parent = n
+		}
+	}
+	return parent
+}
@@ -0,0 +1,344 @@
+package office
+
+import (
+	"archive/zip"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/geistblitz/boringformat/internal/launder"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const nsXLink = "http://www.w3.org/1999/xlink"
+
+func OpenODT(name string) (*launder.Document, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return readODT(&zr.Reader)
+}
+
+func ReadODT(r io.ReaderAt, size int64) (*launder.Document, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return readODT(zr)
+}
+
+type odtReader struct {
+	*builder
+	archive    *archive
+	textStyles map[string]runFormat
+	paraStyles map[string]string
+	listStyles map[string][]bool
+	headings   map[string]int
+}
+
+func readODT(zr *zip.Reader) (*launder.Document, error) {
+	r := &odtReader{
+		archive:    newArchive(zr),
+		textStyles: make(map[string]runFormat),
+		paraStyles: make(map[string]string),
+		listStyles: make(map[string][]bool),
+		headings:   make(map[string]int),
+	}
+
+	title := ""
+	if r.archive.has("meta.xml") {
+		if meta, err := r.archive.parse("meta.xml"); err == nil {
+			if t := meta.find("title"); t != nil {
+				title = strings.TrimSpace(t.text())
+			}
+		}
+	}
+	r.builder = newBuilder(title)
+
+	if r.archive.has("styles.xml") {
+		if styles, err := r.archive.parse("styles.xml"); err == nil {
+			r.loadStyles(styles)
+		}
+	}
+	content, err := r.archive.parse("content.xml")
+	if err != nil {
+		return nil, err
+	}
+	r.loadStyles(content)
+
+	if text := content.find("text"); text != nil {
+		r.blocks(text)
+	}
+	return r.document(), nil
+}
+
+func (r *odtReader) loadStyles(root *xmlNode) {
+	for _, s := range root.findAll("style") {
+		name := s.attr("name")
+		if props := s.child("text-properties"); props != nil {
+			r.textStyles[name] = runFormat{
+				bold:      props.attr("font-weight") == "bold",
+				italic:    props.attr("font-style") == "italic",
+				underline: props.attr("text-underline-style") != "" && props.attr("text-underline-style") != "none",
+				strike:    props.attr("text-line-through-style") != "" && props.attr("text-line-through-style") != "none",
+				sup:       strings.HasPrefix(props.attr("text-position"), "super"),
+				sub:       strings.HasPrefix(props.attr("text-position"), "sub"),
+			}
+		}
+		if parent := s.attr("parent-style-name"); parent != "" {
+			r.paraStyles[name] = parent
+		}
+		if lvl := s.attr("default-outline-level"); lvl != "" {
+			r.headings[name], _ = strconv.Atoi(lvl)
+		}
+	}
+
+	for _, ls := range root.findAll("list-style") {
+		var levels []bool
+		for _, c := range ls.Children {
+			if c.isText() {
+				continue
+			}
+			lvl, _ := strconv.Atoi(c.attr("level"))
+			for len(levels) < lvl {
+				levels = append(levels, false)
+			}
+			if lvl > 0 {
+				levels[lvl-1] = c.Name.Local == "list-level-style-number"
+			}
+		}
+		r.listStyles[ls.attr("name")] = levels
+	}
+}
+
+func (r *odtReader) headingLevel(style string) int {
+	for i := 0; style != "" && i < 8; i++ {
+		if lvl, ok := r.headings[style]; ok {
+			return lvl
+		}
+		style = r.paraStyles[style]
+	}
+	return 0
+}
+
+func (r *odtReader) blocks(parent *xmlNode) {
+	for _, c := range parent.Children {
+		switch c.Name.Local {
+		case "h":
+			level, _ := strconv.Atoi(c.attr("outline-level"))
+			h := heading(level)
+			r.inlines(h, c)
+			r.block(h)
+		case "p":
+			var n *html.Node
+			if level := r.headingLevel(c.attr("style-name")); level > 0 {
+				n = heading(level)
+			} else {
+				n = element(atom.P)
+			}
+			r.inlines(n, c)
+			if n.FirstChild != nil {
+				r.block(n)
+			}
+		case "list":
+			r.lists = nil
+			r.list(c, c.attr("style-name"), 0)
+			r.lists = nil
+		case "table":
+			r.block(r.table(c))
+		case "section":
+			r.blocks(c)
+		}
+	}
+}
+
+func (r *odtReader) list(list *xmlNode, style string, level int) {
+	if s := list.attr("style-name"); s != "" {
+		style = s
+	}
+	ordered := false
+	if levels := r.listStyles[style]; level < len(levels) {
+		ordered = levels[level]
+	}
+
+	for _, item := range list.Children {
+		if item.Name.Local != "list-item" && item.Name.Local != "list-header" {
+			continue
+		}
+		li := r.listItem(level, ordered)
+
+		var paras []*xmlNode
+		for _, c := range item.Children {
+			if c.Name.Local == "p" || c.Name.Local == "h" {
+				paras = append(paras, c)
+			}
+		}
+		for _, c := range item.Children {
+			switch c.Name.Local {
+			case "p", "h":
+				if len(paras) == 1 {
+					r.inlines(li, c)
+				} else {
+					p := element(atom.P)
+					r.inlines(p, c)
+					li.AppendChild(p)
+				}
+			case "list":
+				r.list(c, style, level+1)
+			}
+		}
+	}
+}
+
+func (r *odtReader) inlines(parent *html.Node, n *xmlNode) {
+	for _, c := range n.Children {
+		if c.isText() {
+			appendText(parent, collapseSpace(c.Text))
+			continue
+		}
+
+		switch c.Name.Local {
+		case "span":
+			target := r.textStyles[c.attr("style-name")].apply(parent)
+			r.inlines(target, c)
+		case "a":
+			a := element(atom.A)
+			if href := c.attrNS(nsXLink, "href"); href != "" {
+				a.Attr = []html.Attribute{{Key: "href", Val: href}}
+			}
+			parent.AppendChild(a)
+			r.inlines(a, c)
+		case "s":
+			n, err := strconv.Atoi(c.attr("c"))
+			if err != nil || n < 1 {
+				n = 1
+			}
+			appendText(parent, strings.Repeat(" ", n))
+		case "tab":
+			appendText(parent, "\t")
+		case "line-break":
+			parent.AppendChild(element(atom.Br))
+		case "bookmark", "bookmark-start":
+			a := element(atom.A)
+			a.Attr = []html.Attribute{{Key: "id", Val: c.attr("name")}}
+			parent.AppendChild(a)
+		case "note":
+			id := c.attr("id")
+			if id == "" {
+				id = strconv.Itoa(r.noteCount + 1)
+			}
+			sup, li := r.noteReference(id)
+			parent.AppendChild(sup)
+			if body := c.child("note-body"); body != nil {
+				for i, p := range body.findAll("p") {
+					if i > 0 {
+						appendText(li, " ")
+					}
+					r.inlines(li, p)
+				}
+			}
+		case "frame":
+			if img := r.image(c); img != nil {
+				parent.AppendChild(img)
+			}
+		case "soft-page-break", "bookmark-end", "reference-mark", "reference-mark-start", "reference-mark-end":
+		default:
+			r.inlines(parent, c)
+		}
+	}
+}
+
+func (r *odtReader) image(frame *xmlNode) *html.Node {
+	im := frame.child("image")
+	if im == nil {
+		return nil
+	}
+	img := element(atom.Img)
+	img.Attr = []html.Attribute{{Key: "src", Val: im.attrNS(nsXLink, "href")}}
+
+	alt := ""
+	if t := frame.child("desc"); t != nil {
+		alt = t.text()
+	} else if t := frame.child("title"); t != nil {
+		alt = t.text()
+	}
+	img.Attr = append(img.Attr, html.Attribute{Key: "alt", Val: strings.TrimSpace(alt)})
+	return img
+}
+
+func (r *odtReader) table(tbl *xmlNode) *html.Node {
+	table := element(atom.Table)
+
+	var rows func(n *xmlNode, header bool)
+	rows = func(n *xmlNode, header bool) {
+		for _, c := range n.Children {
+			switch c.Name.Local {
+			case "table-header-rows":
+				rows(c, true)
+			case "table-rows", "table-row-group":
+				rows(c, header)
+			case "table-row":
+				table.AppendChild(r.row(c, header))
+			}
+		}
+	}
+	rows(tbl, false)
+	return table
+}
+
+func (r *odtReader) row(row *xmlNode, header bool) *html.Node {
+	tr := element(atom.Tr)
+	for _, c := range row.Children {
+		if c.Name.Local != "table-cell" {
+			continue
+		}
+		cell := element(atom.Td)
+		if header {
+			cell = element(atom.Th)
+		}
+		if span := c.attr("number-columns-spanned"); span != "" && span != "1" {
+			cell.Attr = append(cell.Attr, html.Attribute{Key: "colspan", Val: span})
+		}
+		if span := c.attr("number-rows-spanned"); span != "" && span != "1" {
+			cell.Attr = append(cell.Attr, html.Attribute{Key: "rowspan", Val: span})
+		}
+
+		var paras []*xmlNode
+		for _, p := range c.Children {
+			if p.Name.Local == "p" || p.Name.Local == "h" {
+				paras = append(paras, p)
+			}
+		}
+		if len(paras) == 1 {
+			r.inlines(cell, paras[0])
+		} else {
+			for _, p := range paras {
+				para := element(atom.P)
+				r.inlines(para, p)
+				cell.AppendChild(para)
+			}
+		}
+		tr.AppendChild(cell)
+	}
+	return tr
+}
+
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,197 @@
+package office
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/geistblitz/boringformat/internal/launder"
+)
+
+func buildZip(t *testing.T, files map[string]string) *bytes.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+const docxNS = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+	`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" ` +
+	`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"`
+
+var docxFiles = map[string]string{
+	"docProps/core.xml": `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+		`xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Report</dc:title></cp:coreProperties>`,
+	"word/_rels/document.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="hyperlink" Target="https://example.com/" TargetMode="External"/>` +
+		`<Relationship Id="rId2" Type="image" Target="media/image1.png"/></Relationships>`,
+	"word/styles.xml": `<w:styles ` + docxNS + `>` +
+		`<w:style w:styleId="Heading1"><w:name w:val="heading 1"/></w:style>` +
+		`<w:style w:styleId="Heading2"><w:name w:val="heading 2"/></w:style></w:styles>`,
+	"word/numbering.xml": `<w:numbering ` + docxNS + `>` +
+		`<w:abstractNum w:abstractNumId="0"><w:lvl w:ilvl="0"><w:numFmt w:val="bullet"/></w:lvl>` +
+		`<w:lvl w:ilvl="1"><w:numFmt w:val="decimal"/></w:lvl></w:abstractNum>` +
+		`<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num></w:numbering>`,
+	"word/footnotes.xml": `<w:footnotes ` + docxNS + `>` +
+		`<w:footnote w:id="1"><w:p><w:r><w:t>A note.</w:t></w:r></w:p></w:footnote></w:footnotes>`,
+	"word/document.xml": `<w:document ` + docxNS + `><w:body>` +
+		`<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Intro</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:rPr><w:b/></w:rPr><w:t>Bold</w:t></w:r><w:r><w:t xml:space="preserve"> and </w:t></w:r>` +
+		`<w:hyperlink r:id="rId1"><w:r><w:t>link</w:t></w:r></w:hyperlink>` +
+		`<w:r><w:footnoteReference w:id="1"/></w:r></w:p>` +
+		`<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>One</w:t></w:r></w:p>` +
+		`<w:p><w:pPr><w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Nested</w:t></w:r></w:p>` +
+		`<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Two</w:t></w:r></w:p>` +
+		`<w:p><w:pPr><w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t>Data</w:t></w:r></w:p>` +
+		`<w:tbl><w:tr><w:trPr><w:tblHeader/></w:trPr><w:tc><w:p><w:r><w:t>A</w:t></w:r></w:p></w:tc>` +
+		`<w:tc><w:p><w:r><w:t>B</w:t></w:r></w:p></w:tc></w:tr>` +
+		`<w:tr><w:tc><w:tcPr><w:gridSpan w:val="2"/></w:tcPr><w:p><w:r><w:t>Wide</w:t></w:r></w:p></w:tc></w:tr></w:tbl>` +
+		`<w:p><w:r><w:drawing><wp:inline><wp:docPr id="1" name="Picture" descr="A chart"/>` +
+		`<a:graphic><a:graphicData><a:blip r:embed="rId2"/></a:graphicData></a:graphic></wp:inline></w:drawing></w:r></w:p>` +
+		`</w:body></w:document>`,
+}
+
+const odtNS = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" ` +
+	`xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" ` +
+	`xmlns:svg="urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0" ` +
+	`xmlns:dc="http://purl.org/dc/elements/1.1/" ` +
+	`xmlns:xlink="http://www.w3.org/1999/xlink"`
+
+var odtFiles = map[string]string{
+	"mimetype": "application/vnd.oasis.opendocument.text",
+	"meta.xml": `<office:document-meta ` + odtNS + `><office:meta><dc:title>Report</dc:title></office:meta></office:document-meta>`,
+	"content.xml": `<office:document-content ` + odtNS + `><office:automatic-styles>` +
+		`<style:style style:name="T1" style:family="text"><style:text-properties fo:font-weight="bold"/></style:style>` +
+		`<text:list-style style:name="L1"><text:list-level-style-bullet text:level="1"/>` +
+		`<text:list-level-style-number text:level="2"/></text:list-style>` +
+		`</office:automatic-styles><office:body><office:text>` +
+		`<text:h text:outline-level="1">Intro</text:h>` +
+		`<text:p><text:span text:style-name="T1">Bold</text:span> and ` +
+		`<text:a xlink:type="simple" xlink:href="https://example.com/">link</text:a>` +
+		`<text:note text:id="ftn1" text:note-class="footnote"><text:note-citation>1</text:note-citation>` +
+		`<text:note-body><text:p>A note.</text:p></text:note-body></text:note></text:p>` +
+		`<text:list text:style-name="L1"><text:list-item><text:p>One</text:p>` +
+		`<text:list><text:list-item><text:p>Nested</text:p></text:list-item></text:list></text:list-item>` +
+		`<text:list-item><text:p>Two</text:p></text:list-item></text:list>` +
+		`<text:h text:outline-level="2">Data</text:h>` +
+		`<table:table><table:table-header-rows><table:table-row>` +
+		`<table:table-cell><text:p>A</text:p></table:table-cell><table:table-cell><text:p>B</text:p></table:table-cell>` +
+		`</table:table-row></table:table-header-rows><table:table-row>` +
+		`<table:table-cell table:number-columns-spanned="2"><text:p>Wide</text:p></table:table-cell>` +
+		`<table:covered-table-cell/></table:table-row></table:table>` +
+		`<text:p><draw:frame draw:name="Picture"><draw:image xlink:href="Pictures/image1.png"/>` +
+		`<svg:desc>A chart</svg:desc></draw:frame></text:p>` +
+		`</office:text></office:body></office:document-content>`,
+}
+
+func checkDocument(t *testing.T, doc *launder.Document, image string) {
+	t.Helper()
+
+	if title := doc.Find("title").Text(); title != "Report" {
+		t.Errorf("unexpected title %q", title)
+	}
+	if txt := doc.Find("h1").Text(); txt != "Intro" {
+		t.Errorf("unexpected h1 %q", txt)
+	}
+	if txt := doc.Find("h2").Text(); txt != "Data" {
+		t.Errorf("unexpected h2 %q", txt)
+	}
+	if txt := doc.Find("p strong").Text(); txt != "Bold" {
+		t.Errorf("unexpected bold text %q", txt)
+	}
+	if href, _ := doc.Find("p a[href]").Attr("href"); href != "https://example.com/" {
+		t.Errorf("unexpected link %q", href)
+	}
+
+	if n := doc.Find("body > ul > li").Length(); n != 2 {
+		t.Errorf("expected 2 top-level items, found %d", n)
+	}
+	if txt := doc.Find("body > ul > li > ol > li").Text(); txt != "Nested" {
+		t.Errorf("unexpected nested item %q", txt)
+	}
+
+	if n := doc.Find("table th").Length(); n != 2 {
+		t.Errorf("expected 2 header cells, found %d", n)
+	}
+	if span, _ := doc.Find("table td").Attr("colspan"); span != "2" {
+		t.Errorf("unexpected colspan %q", span)
+	}
+
+	ref := doc.Find("sup a")
+	href, _ := ref.Attr("href")
+	if note := doc.Find("section.footnotes li"); note.Length() != 1 || "#"+note.AttrOr("id", "") != href {
+		t.Errorf("footnote %q not linked to %q", note.AttrOr("id", ""), href)
+	} else if txt := note.Text(); !strings.HasPrefix(txt, "A note.") {
+		t.Errorf("unexpected footnote text %q", txt)
+	}
+
+	img := doc.Find("img")
+	if src, _ := img.Attr("src"); src != image {
+		t.Errorf("unexpected image source %q", src)
+	}
+	if alt, _ := img.Attr("alt"); alt != "A chart" {
+		t.Errorf("unexpected image alt %q", alt)
+	}
+}
+
+func TestReadDOCX(t *testing.T) {
+	r := buildZip(t, docxFiles)
+	doc, err := ReadDOCX(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDocument(t, doc, "word/media/image1.png")
+}
+
+func TestReadODT(t *testing.T) {
+	r := buildZip(t, odtFiles)
+	doc, err := ReadODT(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDocument(t, doc, "Pictures/image1.png")
+}
+
+func TestReadDOCXEdgeCases(t *testing.T) {
+	files := make(map[string]string, len(docxFiles))
+	for k, v := range docxFiles {
+		files[k] = v
+	}
+	files["word/_rels/document.xml.rels"] = strings.Replace(files["word/_rels/document.xml.rels"], `Target="media/image1.png"`, `Target="/word/media/image1.png"`, 1)
+	files["word/document.xml"] = `<w:document ` + docxNS + `><w:body>` +
+		`<w:p><w:pPr><w:numPr><w:ilvl w:val="1000000000"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Deep</w:t></w:r></w:p>` +
+		`<w:p><w:pPr><w:numPr><w:ilvl w:val="-3"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Shallow</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:drawing><wp:inline><wp:docPr id="1" name="Picture" descr="A chart"/>` +
+		`<a:graphic><a:graphicData><a:blip r:embed="rId2"/></a:graphicData></a:graphic></wp:inline></w:drawing></w:r></w:p>` +
+		`</w:body></w:document>`
+
+	r := buildZip(t, files)
+	doc, err := ReadDOCX(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := doc.Find("li li li li li li li li li").Length(); n != 1 || doc.Find("li li li li li li li li li li").Length() != 0 {
+		t.Errorf("expected list nesting to stop at nine levels, found %d", n)
+	}
+	if txt := doc.Find("body > ul > li").Last().Text(); txt != "Shallow" {
+		t.Errorf("expected a negative level to map to the top list, got %q", txt)
+	}
+	if src, _ := doc.Find("img").Attr("src"); src != "word/media/image1.png" {
+		t.Errorf("unexpected image source for an absolute target %q", src)
+	}
+}
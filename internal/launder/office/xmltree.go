@@ -0,0 +1,145 @@
+package office
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type xmlNode struct {
+	Name     xml.Name
+	Attr     []xml.Attr
+	Children []*xmlNode
+	Text     string
+}
+
+func parseXML(r io.Reader) (*xmlNode, error) {
+	dec := xml.NewDecoder(r)
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{Name: t.Name, Attr: t.Attr}
+			top.Children = append(top.Children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			top.Children = append(top.Children, &xmlNode{Text: string(t)})
+		}
+	}
+	return root, nil
+}
+
+func (n *xmlNode) isText() bool {
+	return n.Name.Local == ""
+}
+
+func (n *xmlNode) attr(local string) string {
+	for _, a := range n.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *xmlNode) attrNS(space, local string) string {
+	for _, a := range n.Attr {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *xmlNode) child(local string) *xmlNode {
+	for _, c := range n.Children {
+		if c.Name.Local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+func (n *xmlNode) find(local string) *xmlNode {
+	for _, c := range n.Children {
+		if c.Name.Local == local {
+			return c
+		}
+		if f := c.find(local); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func (n *xmlNode) findAll(local string) []*xmlNode {
+	var result []*xmlNode
+	for _, c := range n.Children {
+		if c.Name.Local == local {
+			result = append(result, c)
+		} else {
+			result = append(result, c.findAll(local)...)
+		}
+	}
+	return result
+}
+
+func (n *xmlNode) text() string {
+	if n.isText() {
+		return n.Text
+	}
+	var b strings.Builder
+	for _, c := range n.Children {
+		b.WriteString(c.text())
+	}
+	return b.String()
+}
+
+type archive struct {
+	files map[string]*zip.File
+}
+
+func newArchive(zr *zip.Reader) *archive {
+	a := &archive{files: make(map[string]*zip.File)}
+	for _, f := range zr.File {
+		a.files[f.Name] = f
+	}
+	return a
+}
+
+func (a *archive) has(name string) bool {
+	_, ok := a.files[name]
+	return ok
+}
+
+func (a *archive) parse(name string) (*xmlNode, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("office: %s not found", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	n, err := parseXML(rc)
+	if err != nil {
+		return nil, fmt.Errorf("office: %s: %w", name, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,40 @@
+package parser
+
+func Tag(tag string) Sel {
+	return tagSelector{tag: toLowerASCII(tag)}
+}
+
+func ID(id string) Sel {
+	return idSelector{id: id}
+}
+
+func Class(class string) Sel {
+	return classSelector{class: class}
+}
+
+func AttrExists(key string) Sel {
+	return attrSelector{key: toLowerASCII(key)}
+}
+
+func AttrEquals(key, val string) Sel {
+	return attrSelector{key: toLowerASCII(key), val: val, operation: "="}
+}
+
+func NthChild(a, b int, last, ofType bool) Sel {
+	return nthPseudoClassSelector{a: a, b: b, last: last, ofType: ofType}
+}
+
+func Compound(sels ...Sel) Sel {
+	if len(sels) == 1 {
+		return sels[0]
+	}
+	return compoundSelector{selectors: sels}
+}
+
+func Descendant(ancestor, sel Sel) Sel {
+	return combinedSelector{first: ancestor, combinator: ' ', second: sel}
+}
+
+func Child(parent, sel Sel) Sel {
+	return combinedSelector{first: parent, combinator: '>', second: sel}
+}
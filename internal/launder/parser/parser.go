@@ -13,6 +13,7 @@ type parser struct {
 	i int   
 
 	acceptPseudoElements bool
+	pseudoClasses        map[string]PseudoClassFunc
 }
 
 func (p *parser) parseEscape() (result string, err error) {
@@ -566,11 +567,52 @@ func (p *parser) parsePseudoclassSelector() (out Sel, pseudoElement string, err
 	case "after", "backdrop", "before", "cue", "first-letter", "first-line", "grammar-error", "marker", "placeholder", "selection", "spelling-error":
 		return nil, name, nil
 	default:
-		return out, "", fmt.Errorf("unknown pseudoclass or pseudoelement :%s", name)
+		f, ok := p.pseudoClasses[name]
+		if !ok {
+			return out, "", fmt.Errorf("unknown pseudoclass or pseudoelement :%s", name)
+		}
+		var args string
+		if p.consumeParenthesis() {
+			if args, err = p.parseRawArgument(); err != nil {
+				return out, "", err
+			}
+		}
+		m, ferr := f(args)
+		if ferr != nil {
+			return out, "", fmt.Errorf(":%s(%s): %w", name, args, ferr)
+		}
+		out = customPseudoClassSelector{name: name, args: args, match: m}
 	}
 	return
 }
 
+func (p *parser) parseRawArgument() (string, error) {
+	start, depth := p.i, 0
+	for p.i < len(p.s) {
+		switch c := p.s[p.i]; c {
+		case '\\':
+			p.i++
+		case '\'', '"':
+			for p.i++; p.i < len(p.s) && p.s[p.i] != c; p.i++ {
+				if p.s[p.i] == '\\' {
+					p.i++
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				arg := strings.TrimSpace(p.s[start:p.i])
+				p.i++
+				return arg, nil
+			}
+			depth--
+		}
+		p.i++
+	}
+	return "", errUnmatchedParenthesis
+}
+
 func (p *parser) parseInteger() (int, error) {
 	i := p.i
 	start := i
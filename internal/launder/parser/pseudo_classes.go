@@ -74,6 +74,19 @@ func (c relativePseudoClassSelector) PseudoElement() string {
 	return ""
 }
 
+type PseudoClassFunc func(args string) (Matcher, error)
+
+type customPseudoClassSelector struct {
+	abstractPseudoClass
+	name  string
+	args  string
+	match Matcher
+}
+
+func (s customPseudoClassSelector) Match(n *html.Node) bool {
+	return s.match.Match(n)
+}
+
 type containsPseudoClassSelector struct {
 	abstractPseudoClass
 	value string
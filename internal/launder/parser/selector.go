@@ -63,6 +63,20 @@ func ParseGroup(sel string) (SelectorGroup, error) {
 	return compiled, nil
 }
 
+func ParseGroupWithPseudoClasses(sel string, classes map[string]PseudoClassFunc) (SelectorGroup, error) {
+	p := &parser{s: sel, pseudoClasses: classes}
+	compiled, err := p.parseSelectorGroup()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.i < len(sel) {
+		return nil, fmt.Errorf("parsing %q: %d bytes left over", sel, len(sel)-p.i)
+	}
+
+	return compiled, nil
+}
+
 func ParseGroupWithPseudoElements(sel string) (SelectorGroup, error) {
 	p := &parser{s: sel, acceptPseudoElements: true}
 	compiled, err := p.parseSelectorGroup()
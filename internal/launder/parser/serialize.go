@@ -8,6 +8,8 @@ import (
 
 var specialCharReplacer *strings.Replacer
 
+var quoteReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\a `, "\r", `\d `, "\f", `\c `)
+
 func init() {
 	var pairs []string
 	for _, s := range ",!\"#$%&'()*+ -./:;<=>?@[\\]^`{|}~" {
@@ -18,16 +20,36 @@ func init() {
 
 func escape(s string) string { return specialCharReplacer.Replace(s) }
 
+func EscapeIdentifier(s string) string {
+	if s == "" {
+		return s
+	}
+	prefix := ""
+	rest := s
+	if rest[0] == '-' {
+		prefix, rest = "-", rest[1:]
+	}
+	if rest != "" && '0' <= rest[0] && rest[0] <= '9' {
+		prefix += fmt.Sprintf("\\%x ", rest[0])
+		rest = rest[1:]
+	}
+	return prefix + escape(rest)
+}
+
+func QuoteString(s string) string {
+	return `"` + quoteReplacer.Replace(s) + `"`
+}
+
 func (c tagSelector) String() string {
 	return c.tag
 }
 
 func (c idSelector) String() string {
-	return "#" + escape(c.id)
+	return "#" + EscapeIdentifier(c.id)
 }
 
 func (c classSelector) String() string {
-	return "." + escape(c.class)
+	return "." + EscapeIdentifier(c.class)
 }
 
 func (c attrSelector) String() string {
@@ -35,7 +57,7 @@ func (c attrSelector) String() string {
 	if c.operation == "#=" {
 		val = c.regexp.String()
 	} else if c.operation != "" {
-		val = fmt.Sprintf(`"%s"`, val)
+		val = QuoteString(val)
 	}
 
 	ignoreCase := ""
@@ -51,6 +73,13 @@ func (c relativePseudoClassSelector) String() string {
 	return fmt.Sprintf(":%s(%s)", c.name, c.match.String())
 }
 
+func (c customPseudoClassSelector) String() string {
+	if c.args == "" {
+		return ":" + c.name
+	}
+	return fmt.Sprintf(":%s(%s)", c.name, c.args)
+}
+
 func (c containsPseudoClassSelector) String() string {
 	s := "contains"
 	if c.own {
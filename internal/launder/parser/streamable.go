@@ -0,0 +1,51 @@
+package parser
+
+import "fmt"
+
+func Streamable(m Matcher) error {
+	switch s := m.(type) {
+	case tagSelector, classSelector, idSelector, attrSelector, neverMatchSelector,
+		inputPseudoClassSelector, rootPseudoClassSelector, linkPseudoClassSelector,
+		langPseudoClassSelector, checkedPseudoClassSelector:
+		return nil
+	case compoundSelector:
+		if s.pseudoElement != "" {
+			return fmt.Errorf("%s: pseudo-elements cannot be streamed", s)
+		}
+		for _, sel := range s.selectors {
+			if err := Streamable(sel); err != nil {
+				return err
+			}
+		}
+		return nil
+	case combinedSelector:
+		switch s.combinator {
+		case 0, ' ', '>':
+		default:
+			return fmt.Errorf("%s: sibling combinators cannot be streamed", s)
+		}
+		if err := Streamable(s.first); err != nil {
+			return err
+		}
+		if s.second != nil {
+			return Streamable(s.second)
+		}
+		return nil
+	case relativePseudoClassSelector:
+		if s.name != "not" {
+			return fmt.Errorf("%s: :%s needs the element's descendants", s, s.name)
+		}
+		return Streamable(s.match)
+	case SelectorGroup:
+		for _, sel := range s {
+			if err := Streamable(sel); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Sel:
+		return fmt.Errorf("%s: selector needs siblings, descendants or text", s)
+	default:
+		return fmt.Errorf("matcher %T cannot be streamed", m)
+	}
+}
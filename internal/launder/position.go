@@ -0,0 +1,305 @@
+package launder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type Location struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (l Location) IsValid() bool {
+	return l.Line > 0
+}
+
+func (l Location) String() string {
+	if !l.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", l.Line, l.Column)
+}
+
+type Span struct {
+	Start Location
+	End   Location
+}
+
+type Position struct {
+	Start    Location
+	End      Location
+	StartTag Span
+	EndTag   Span
+}
+
+func (p Position) IsValid() bool {
+	return p.Start.IsValid()
+}
+
+func (p Position) String() string {
+	return p.Start.String()
+}
+
+func NewDocumentFromReaderWithPositions(r io.Reader, contentType string) (*Document, error) {
+	raw, e := io.ReadAll(r)
+	if e != nil {
+		return nil, e
+	}
+	src, offsets, enc, e := decodeWithOffsets(raw, contentType)
+	if e != nil {
+		return nil, e
+	}
+	root, e := html.Parse(bytes.NewReader(src))
+	if e != nil {
+		return nil, e
+	}
+
+	d := newDocument(root, nil)
+	d.Encoding = enc
+	d.positions = sourcePositions(src, root)
+	if offsets != nil {
+		remapOffsets(d.positions, offsets)
+	}
+	return d, nil
+}
+
+func remapOffsets(positions map[*html.Node]Position, offsets []int) {
+	remap := func(l *Location) {
+		if l.IsValid() && l.Offset < len(offsets) {
+			l.Offset = offsets[l.Offset]
+		}
+	}
+	for n, p := range positions {
+		for _, l := range []*Location{&p.Start, &p.End, &p.StartTag.Start, &p.StartTag.End, &p.EndTag.Start, &p.EndTag.End} {
+			remap(l)
+		}
+		positions[n] = p
+	}
+}
+
+func (d *Document) HasPositions() bool {
+	return d.positions != nil
+}
+
+func (s *Selection) Position() Position {
+	if len(s.Nodes) == 0 || s.document == nil {
+		return Position{}
+	}
+	return s.document.positions[s.Nodes[0]]
+}
+
+func (s *Selection) Positions() []Position {
+	out := make([]Position, len(s.Nodes))
+	if s.document == nil {
+		return out
+	}
+	for i, n := range s.Nodes {
+		out[i] = s.document.positions[n]
+	}
+	return out
+}
+
+func (d *Document) describe(n *html.Node) string {
+	var desc string
+	switch n.Type {
+	case html.ElementNode:
+		desc = "<" + n.Data + ">"
+	case html.TextNode:
+		desc = "text node"
+	case html.CommentNode:
+		desc = "comment"
+	case html.DocumentNode:
+		desc = "document"
+	default:
+		desc = "node"
+	}
+	if p, ok := d.positions[n]; ok && p.IsValid() {
+		desc += " at " + p.String()
+	}
+	return desc
+}
+
+func copyPositions(from, to map[*html.Node]Position, src, dst *html.Node) {
+	if p, ok := from[src]; ok {
+		to[dst] = p
+	}
+	for s, d := src.FirstChild, dst.FirstChild; s != nil && d != nil; s, d = s.NextSibling, d.NextSibling {
+		copyPositions(from, to, s, d)
+	}
+}
+
+type lineIndex []int
+
+func newLineIndex(src []byte) lineIndex {
+	idx := lineIndex{0}
+	for i, c := range src {
+		if c == '\n' {
+			idx = append(idx, i+1)
+		}
+	}
+	return idx
+}
+
+func (idx lineIndex) location(src []byte, off int) Location {
+	line := sort.Search(len(idx), func(i int) bool { return idx[i] > off }) - 1
+	return Location{
+		Offset: off,
+		Line:   line + 1,
+		Column: utf8.RuneCount(src[idx[line]:off]) + 1,
+	}
+}
+
+type sourceTag struct {
+	name   string
+	start  int
+	end    int
+	close  [2]int
+	closed bool
+}
+
+type sourceText struct {
+	data  string
+	start int
+	end   int
+}
+
+func sourcePositions(src []byte, root *html.Node) map[*html.Node]Position {
+	idx := newLineIndex(src)
+	span := func(start, end int) Span {
+		return Span{idx.location(src, start), idx.location(src, end)}
+	}
+
+	tags := make(map[string][]*sourceTag)
+	var texts []sourceText
+	open := make(map[string][]*sourceTag)
+
+	z := html.NewTokenizer(bytes.NewReader(src))
+	off := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		start := off
+		off += len(z.Raw())
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			t := &sourceTag{name: string(name), start: start, end: off}
+			tags[t.name] = append(tags[t.name], t)
+			if tt == html.StartTagToken && !voidElements[atom.Lookup(name)] {
+				open[t.name] = append(open[t.name], t)
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if stack := open[string(name)]; len(stack) > 0 {
+				t := stack[len(stack)-1]
+				open[string(name)] = stack[:len(stack)-1]
+				t.close, t.closed = [2]int{start, off}, true
+			}
+		case html.TextToken:
+			texts = append(texts, sourceText{string(z.Text()), start, off})
+		}
+	}
+
+	positions := make(map[*html.Node]Position)
+	next := make(map[string]int)
+	textAt := 0
+
+	firstTag := func(n *html.Node) *sourceTag {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			name := strings.ToLower(c.Data)
+			if i := next[name]; i < len(tags[name]) {
+				return tags[name][i]
+			}
+		}
+		return nil
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.ElementNode:
+			name := strings.ToLower(n.Data)
+			if i := next[name]; i < len(tags[name]) {
+				t := tags[name][i]
+				if ch := firstTag(n); !impliedElements[name] || ch == nil || t.start < ch.start {
+					next[name]++
+					p := Position{StartTag: span(t.start, t.end)}
+					if t.closed {
+						p.EndTag = span(t.close[0], t.close[1])
+					}
+					positions[n] = p
+				}
+			}
+		case html.TextNode:
+			if p, ok := matchText(texts, &textAt, n.Data); ok {
+				positions[n] = Position{Start: idx.location(src, p[0]), End: idx.location(src, p[1])}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+
+		if n.Type != html.ElementNode {
+			return
+		}
+		p, ok := positions[n]
+		if !ok {
+			return
+		}
+		p.Start = p.StartTag.Start
+		p.End = p.EndTag.End
+		if !p.EndTag.Start.IsValid() {
+			p.End = p.StartTag.End
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if cp, ok := positions[c]; ok && cp.End.Offset > p.End.Offset {
+					p.End = cp.End
+				}
+			}
+		}
+		positions[n] = p
+	}
+	walk(root)
+	return positions
+}
+
+var impliedElements = map[string]bool{
+	"html": true, "head": true, "body": true, "tbody": true, "colgroup": true,
+}
+
+func matchText(texts []sourceText, at *int, data string) ([2]int, bool) {
+	for i := *at; i < len(texts) && i < *at+8; i++ {
+		d := data
+		if strings.HasPrefix(texts[i].data, "\n") && !strings.HasPrefix(d, "\n") {
+			d = "\n" + d
+		}
+		if !strings.HasPrefix(d, texts[i].data) {
+			continue
+		}
+
+		span := [2]int{texts[i].start, texts[i].end}
+		consumed := len(texts[i].data)
+		j := i + 1
+		for ; j < len(texts) && consumed < len(d) && strings.HasPrefix(d[consumed:], texts[j].data); j++ {
+			consumed += len(texts[j].data)
+			span[1] = texts[j].end
+		}
+		*at = j
+		return span, true
+	}
+	return [2]int{}, false
+}
@@ -0,0 +1,152 @@
+package launder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+const positionPage = `<!DOCTYPE html>
+<html>
+<head><title>Positions</title></head>
+<body>
+  <div id="main">
+    <p class="a">first &amp; only</p>
+    <ul><li>one<li>twö <b>bold</b></ul>
+    <table><tr><td>cell</td></tr></table>
+  </div>
+</body>
+</html>`
+
+func TestPositions(t *testing.T) {
+	d, err := NewDocumentFromReaderWithPositions(strings.NewReader(positionPage), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.HasPositions() {
+		t.Fatal("expected positions to be recorded")
+	}
+
+	cases := []struct {
+		sel   string
+		start string
+		end   string
+	}{
+		{"#main", "5:3", "9:9"},
+		{"p.a", "6:5", "6:38"},
+		{"li:nth-child(2)", "7:16", "7:35"},
+		{"b", "7:24", "7:35"},
+		{"td", "8:16", "8:29"},
+		{"title", "3:7", "3:31"},
+	}
+	for _, c := range cases {
+		p := d.Find(c.sel).Position()
+		if p.Start.String() != c.start || p.End.String() != c.end {
+			t.Errorf("%s: expected %s-%s, got %s-%s", c.sel, c.start, c.end, p.Start, p.End)
+		}
+	}
+
+	p := d.Find("p.a").Position()
+	if tag := positionPage[p.StartTag.Start.Offset:p.StartTag.End.Offset]; tag != `<p class="a">` {
+		t.Errorf("unexpected start tag %q", tag)
+	}
+	if tag := positionPage[p.EndTag.Start.Offset:p.EndTag.End.Offset]; tag != "</p>" {
+		t.Errorf("unexpected end tag %q", tag)
+	}
+
+	text := d.Find("p.a").Contents().Position()
+	if src := positionPage[text.Start.Offset:text.End.Offset]; src != "first &amp; only" {
+		t.Errorf("unexpected text span %q", src)
+	}
+	if d.Find("tbody").Position().IsValid() {
+		t.Error("implied tbody should have no position")
+	}
+
+	clone := CloneDocument(d)
+	if got := clone.Find("b").Position(); got != d.Find("b").Position() {
+		t.Errorf("clone lost position: %+v", got)
+	}
+}
+
+func TestPositionsInErrors(t *testing.T) {
+	d, err := NewDocumentFromReaderWithPositions(strings.NewReader(positionPage), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := &SerializedOffsetMap{Spans: []SerializedSpan{{Start: 0, End: 50, Path: "p.a", Child: 0}}}
+	if _, err := sm.Resolve(d); err == nil || !strings.Contains(err.Error(), "text node at 6:18") {
+		t.Errorf("expected position in error, got %v", err)
+	}
+}
+
+func TestPositionsInOriginalEncoding(t *testing.T) {
+	page := "<html><head><meta charset=\"%s\"></head><body>\n<p id=\"a\">%s</p>\n<p id=\"b\">end</p></body></html>"
+	cases := []struct {
+		charset string
+		enc     encoding.Encoding
+		text    string
+	}{
+		{"shift_jis", japanese.ShiftJIS, "日本語のテキスト"},
+		{"windows-1251", charmap.Windows1251, "Привет, мир"},
+	}
+	for _, c := range cases {
+		raw, err := c.enc.NewEncoder().Bytes([]byte(fmt.Sprintf(page, c.charset, c.text)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := NewDocumentFromReaderWithPositions(bytes.NewReader(raw), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Encoding != c.charset {
+			t.Errorf("%s: detected %q", c.charset, d.Encoding)
+		}
+
+		text := d.Find("#a").Contents().Position()
+		src, err := c.enc.NewDecoder().Bytes(raw[text.Start.Offset:text.End.Offset])
+		if err != nil || string(src) != c.text {
+			t.Errorf("%s: text span decodes to %q", c.charset, src)
+		}
+		b := d.Find("#b").Position()
+		if tag := string(raw[b.StartTag.Start.Offset:b.StartTag.End.Offset]); tag != `<p id="b">` {
+			t.Errorf("%s: unexpected start tag %q", c.charset, tag)
+		}
+		if b.Start.String() != "3:1" || text.End.Column != 11+utf8.RuneCountInString(c.text) {
+			t.Errorf("%s: unexpected locations %s and %+v", c.charset, b.Start, text.End)
+		}
+	}
+
+	bom := append([]byte("\xef\xbb\xbf"), positionPage...)
+	d, err := NewDocumentFromReaderWithPositions(bytes.NewReader(bom), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := d.Find("p.a").Position()
+	if tag := string(bom[p.StartTag.Start.Offset:p.StartTag.End.Offset]); tag != `<p class="a">` {
+		t.Errorf("BOM: unexpected start tag %q", tag)
+	}
+}
+
+func TestPositionsInPatchErrors(t *testing.T) {
+	d, err := NewDocumentFromReaderWithPositions(strings.NewReader(positionPage), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var path []int
+	for n := d.Find("#main").Nodes[0]; n.Parent != nil; n = n.Parent {
+		path = append([]int{childIndex(n)}, path...)
+	}
+	path = append(path, 99)
+	patch := &Patch{Edits: []Edit{{Op: OpDelete, Path: path}}}
+	err = patch.Apply(d)
+	if !errors.Is(err, ErrInvalidPath) || !strings.Contains(err.Error(), "<div> at 5:3") {
+		t.Errorf("expected position in error, got %v", err)
+	}
+}
@@ -31,6 +31,7 @@ func (s *Selection) AttrOr(attrName, defaultValue string) string {
 }
 
 func (s *Selection) RemoveAttr(attrName string) *Selection {
+	s, _ = s.writable()
 	for _, n := range s.Nodes {
 		removeAttr(n, attrName)
 	}
@@ -39,6 +40,7 @@ func (s *Selection) RemoveAttr(attrName string) *Selection {
 }
 
 func (s *Selection) SetAttr(attrName, val string) *Selection {
+	s, _ = s.writable()
 	for _, n := range s.Nodes {
 		attr := getAttributePtr(attrName, n)
 		if attr == nil {
@@ -96,6 +98,43 @@ func (s *Selection) Html() (ret string, e error) {
 	return
 }
 
+func (s *Selection) HtmlAll() (string, error) {
+	return s.renderAll(true)
+}
+
+func (s *Selection) OuterHtmlAll() (string, error) {
+	return s.renderAll(false)
+}
+
+func (s *Selection) HtmlEach() ([]string, error) {
+	return s.renderEach(true)
+}
+
+func (s *Selection) OuterHtmlEach() ([]string, error) {
+	return s.renderEach(false)
+}
+
+func (s *Selection) renderAll(inner bool) (string, error) {
+	var buf bytes.Buffer
+	if err := RenderSelection(&buf, s, &SelectionRenderOptions{Inner: inner}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (s *Selection) renderEach(inner bool) ([]string, error) {
+	out := make([]string, len(s.Nodes))
+	var buf bytes.Buffer
+	for i, n := range s.Nodes {
+		buf.Reset()
+		if err := RenderSelection(&buf, newSingleSelection(n, s.document), &SelectionRenderOptions{Inner: inner}); err != nil {
+			return nil, err
+		}
+		out[i] = buf.String()
+	}
+	return out, nil
+}
+
 func (s *Selection) AddClass(class ...string) *Selection {
 	classStr := strings.TrimSpace(strings.Join(class, " "))
 
@@ -103,6 +142,8 @@ func (s *Selection) AddClass(class ...string) *Selection {
 		return s
 	}
 
+	s, _ = s.writable()
+
 	tcls := getClassesSlice(classStr)
 	for _, n := range s.Nodes {
 		curClasses, attr := getClassesAndAttr(n, true)
@@ -130,6 +171,7 @@ func (s *Selection) HasClass(class string) bool {
 }
 
 func (s *Selection) RemoveClass(class ...string) *Selection {
+	s, _ = s.writable()
 	var rclasses []string
 
 	classStr := strings.TrimSpace(strings.Join(class, " "))
@@ -162,6 +204,8 @@ func (s *Selection) ToggleClass(class ...string) *Selection {
 		return s
 	}
 
+	s, _ = s.writable()
+
 	tcls := getClassesSlice(classStr)
 
 	for _, n := range s.Nodes {
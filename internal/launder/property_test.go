@@ -1,6 +1,7 @@
 package launder
 
 import (
+	"bytes"
 	"regexp"
 	"strings"
 	"testing"
@@ -115,6 +116,34 @@ func TestHtml(t *testing.T) {
 	}
 }
 
+func TestHtmlAll(t *testing.T) {
+	sel := loadString(t, `<p>one <b>1</b></p><div><p>two</p></div><p>three</p>`).Find("p")
+
+	if txt, e := sel.HtmlAll(); e != nil || txt != "one <b>1</b>twothree" {
+		t.Errorf("Unexpected inner HTML %q (%v).", txt, e)
+	}
+	if txt, e := sel.OuterHtmlAll(); e != nil || txt != "<p>one <b>1</b></p><p>two</p><p>three</p>" {
+		t.Errorf("Unexpected outer HTML %q (%v).", txt, e)
+	}
+
+	each, e := sel.OuterHtmlEach()
+	if e != nil || len(each) != 3 || each[1] != "<p>two</p>" {
+		t.Errorf("Unexpected outer HTML list %q (%v).", each, e)
+	}
+	each, e = sel.HtmlEach()
+	if e != nil || len(each) != 3 || each[2] != "three" {
+		t.Errorf("Unexpected inner HTML list %q (%v).", each, e)
+	}
+
+	var buf bytes.Buffer
+	if e := RenderSelection(&buf, sel, &SelectionRenderOptions{Inner: true, Separator: "\n"}); e != nil {
+		t.Fatal(e)
+	}
+	if buf.String() != "one <b>1</b>\ntwo\nthree" {
+		t.Errorf("Unexpected rendered selection %q.", buf.String())
+	}
+}
+
 func TestNbsp(t *testing.T) {
 	src := `<p>Some&nbsp;text</p>`
 	d, err := NewDocumentFromReader(strings.NewReader(src))
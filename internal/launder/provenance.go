@@ -0,0 +1,223 @@
+package launder
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type TextSpan struct {
+	Start  int
+	End    int
+	Node   *html.Node
+	Offset int
+}
+
+type OffsetMap struct {
+	Spans []TextSpan
+}
+
+type SerializedSpan struct {
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Path   string `json:"path"`
+	Child  int    `json:"child"`
+	Offset int    `json:"offset"`
+}
+
+type SerializedOffsetMap struct {
+	Spans []SerializedSpan `json:"spans"`
+}
+
+func (s *Selection) TextWithOffsets() (string, *OffsetMap) {
+	var buf strings.Builder
+	m := &OffsetMap{}
+
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			m.add(buf.Len(), n, 0, len(n.Data))
+			buf.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	for _, n := range s.Nodes {
+		f(n)
+	}
+
+	return buf.String(), m
+}
+
+func (s *Selection) HtmlWithOffsets() (string, *OffsetMap, error) {
+	return s.First().renderWithOffsets(true)
+}
+
+func (s *Selection) OuterHtmlWithOffsets() (string, *OffsetMap, error) {
+	return s.First().renderWithOffsets(false)
+}
+
+func (s *Selection) renderWithOffsets(inner bool) (string, *OffsetMap, error) {
+	var buf bytes.Buffer
+	m, err := RenderSelectionWithOffsets(&buf, s, &SelectionRenderOptions{Inner: inner})
+	if err != nil {
+		return "", nil, err
+	}
+	return buf.String(), m, nil
+}
+
+func (m *OffsetMap) add(start int, n *html.Node, offset, length int) {
+	if length == 0 {
+		return
+	}
+	if l := len(m.Spans); l > 0 {
+		last := &m.Spans[l-1]
+		if last.Node == n && last.End == start && last.Offset+(last.End-last.Start) == offset {
+			last.End += length
+			return
+		}
+	}
+	m.Spans = append(m.Spans, TextSpan{Start: start, End: start + length, Node: n, Offset: offset})
+}
+
+func (m *OffsetMap) Len() int {
+	if len(m.Spans) == 0 {
+		return 0
+	}
+	return m.Spans[len(m.Spans)-1].End
+}
+
+func (m *OffsetMap) Locate(start, end int) []TextSpan {
+	if start >= end {
+		return nil
+	}
+
+	i := sort.Search(len(m.Spans), func(i int) bool {
+		return m.Spans[i].End > start
+	})
+
+	var result []TextSpan
+	for ; i < len(m.Spans) && m.Spans[i].Start < end; i++ {
+		sp := m.Spans[i]
+		s, e := sp.Start, sp.End
+		if s < start {
+			s = start
+		}
+		if e > end {
+			e = end
+		}
+		result = append(result, TextSpan{
+			Start:  s,
+			End:    e,
+			Node:   sp.Node,
+			Offset: sp.Offset + (s - sp.Start),
+		})
+	}
+	return result
+}
+
+func (m *OffsetMap) Slice(start, end int) *OffsetMap {
+	return (&OffsetMap{Spans: m.Locate(start, end)}).Shift(-start)
+}
+
+func (m *OffsetMap) Shift(delta int) *OffsetMap {
+	for i := range m.Spans {
+		m.Spans[i].Start += delta
+		m.Spans[i].End += delta
+	}
+	return m
+}
+
+func (m *OffsetMap) Append(other *OffsetMap, at int) *OffsetMap {
+	for _, sp := range other.Spans {
+		m.add(sp.Start+at, sp.Node, sp.Offset, sp.End-sp.Start)
+	}
+	return m
+}
+
+func (m *OffsetMap) Serialize() (*SerializedOffsetMap, error) {
+	sm := &SerializedOffsetMap{Spans: make([]SerializedSpan, 0, len(m.Spans))}
+	paths := make(map[*html.Node]string)
+	for _, sp := range m.Spans {
+		parent := sp.Node.Parent
+		path, ok := paths[parent]
+		if !ok {
+			switch {
+			case parent == nil:
+				return nil, fmt.Errorf("span %d-%d refers to a detached node", sp.Start, sp.End)
+			case parent.Type == html.ElementNode:
+				path = StableSelector(parent)
+				if path == "" {
+					return nil, fmt.Errorf("span %d-%d has a parent without a unique selector", sp.Start, sp.End)
+				}
+			case parent.Type != html.DocumentNode:
+				return nil, fmt.Errorf("span %d-%d has a parent that is not an element", sp.Start, sp.End)
+			}
+			paths[parent] = path
+		}
+		sm.Spans = append(sm.Spans, SerializedSpan{
+			Start:  sp.Start,
+			End:    sp.End,
+			Path:   path,
+			Child:  childIndex(sp.Node),
+			Offset: sp.Offset,
+		})
+	}
+	return sm, nil
+}
+
+func (sm *SerializedOffsetMap) Resolve(doc *Document) (*OffsetMap, error) {
+	m := &OffsetMap{Spans: make([]TextSpan, 0, len(sm.Spans))}
+	parents := make(map[string]*html.Node)
+
+	for _, sp := range sm.Spans {
+		parent, ok := parents[sp.Path]
+		if !ok && sp.Path == "" {
+			parent = doc.rootNode
+		} else if !ok {
+			sel := doc.Find(sp.Path)
+			if sel.Length() != 1 {
+				if sel.Length() > 1 && doc.HasPositions() {
+					return nil, fmt.Errorf("path %q matched %d nodes, first %s", sp.Path, sel.Length(), doc.describe(sel.Get(0)))
+				}
+				return nil, fmt.Errorf("path %q matched %d nodes", sp.Path, sel.Length())
+			}
+			parent = sel.Get(0)
+			parents[sp.Path] = parent
+		}
+
+		n := nthChild(parent, sp.Child)
+		if n == nil {
+			return nil, fmt.Errorf("path %q has no child %d in %s", sp.Path, sp.Child, doc.describe(parent))
+		}
+		if n.Type != html.TextNode {
+			return nil, fmt.Errorf("path %q child %d is not text: %s", sp.Path, sp.Child, doc.describe(n))
+		}
+		if sp.Offset+(sp.End-sp.Start) > len(n.Data) {
+			return nil, fmt.Errorf("span %d-%d is out of range for %s under %q", sp.Start, sp.End, doc.describe(n), sp.Path)
+		}
+		m.Spans = append(m.Spans, TextSpan{Start: sp.Start, End: sp.End, Node: n, Offset: sp.Offset})
+	}
+
+	return m, nil
+}
+
+func childIndex(n *html.Node) int {
+	i := 0
+	for c := n.PrevSibling; c != nil; c = c.PrevSibling {
+		i++
+	}
+	return i
+}
+
+func nthChild(n *html.Node, i int) *html.Node {
+	c := n.FirstChild
+	for ; c != nil && i > 0; c = c.NextSibling {
+		i--
+	}
+	return c
+}
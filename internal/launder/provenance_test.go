@@ -0,0 +1,183 @@
+package launder
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const provenancePage = `<html><body><div id="main"><p>Hello <b>brave</b> world.</p><p>Second paragraph.</p></div></body></html>`
+
+func TestTextWithOffsets(t *testing.T) {
+	d := loadString(t, provenancePage)
+	text, m := d.Find("#main").TextWithOffsets()
+	if text != "Hello brave world.Second paragraph." {
+		t.Fatalf("unexpected text %q", text)
+	}
+	if m.Len() != len(text) || len(m.Spans) != 4 {
+		t.Fatalf("unexpected spans %+v", m.Spans)
+	}
+
+	spans := m.Locate(8, 20)
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %+v", spans)
+	}
+	if sp := spans[0]; sp.Node.Data != "brave" || sp.Offset != 2 || sp.Start != 8 || sp.End != 11 {
+		t.Errorf("unexpected first span %+v", sp)
+	}
+	if sp := spans[2]; sp.Node.Data != "Second paragraph." || sp.Offset != 0 || sp.End != 20 {
+		t.Errorf("unexpected last span %+v", sp)
+	}
+	if m.Locate(5, 5) != nil {
+		t.Error("empty range should locate nothing")
+	}
+
+	_, first := d.Find("p").First().TextWithOffsets()
+	_, second := d.Find("p").Last().TextWithOffsets()
+	combined := (&OffsetMap{}).Append(first, 0).Append(second, first.Len()+1)
+	if combined.Len() != m.Len()+1 || len(combined.Spans) != 4 {
+		t.Errorf("unexpected appended map %+v", combined.Spans)
+	}
+	if sp := combined.Locate(19, 20); len(sp) != 1 || sp[0].Node.Data != "Second paragraph." {
+		t.Errorf("unexpected span after append %+v", sp)
+	}
+	if combined.Shift(10).Spans[0].Start != 10 {
+		t.Error("expected spans to be shifted")
+	}
+}
+
+func TestOffsetMapRoundTrip(t *testing.T) {
+	d := loadString(t, provenancePage)
+	_, m := d.Find("#main").TextWithOffsets()
+	sm, err := m.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(sm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded SerializedOffsetMap
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	clone := CloneDocument(d)
+	resolved, err := decoded.Resolve(clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolved.Spans) != len(m.Spans) {
+		t.Fatalf("expected %d spans, got %d", len(m.Spans), len(resolved.Spans))
+	}
+	for i, sp := range resolved.Spans {
+		want := m.Spans[i]
+		if sp.Start != want.Start || sp.End != want.End || sp.Offset != want.Offset || sp.Node.Data != want.Node.Data {
+			t.Errorf("span %d: expected %+v, got %+v", i, want, sp)
+		}
+		if sp.Node == want.Node {
+			t.Errorf("span %d should resolve into the clone", i)
+		}
+	}
+}
+
+func TestOffsetMapNonElementParent(t *testing.T) {
+	root := &html.Node{Type: html.DocumentNode}
+	root.AppendChild(&html.Node{Type: html.TextNode, Data: "loose text"})
+	d := NewDocumentFromNode(root)
+
+	_, m := d.TextWithOffsets()
+	sm, err := m.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := sm.Resolve(CloneDocument(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolved.Spans) != 1 || resolved.Spans[0].Node.Data != "loose text" {
+		t.Errorf("unexpected resolved spans %+v", resolved.Spans)
+	}
+
+	detached := &OffsetMap{Spans: []TextSpan{{Start: 0, End: 3, Node: &html.Node{Type: html.TextNode, Data: "abc"}}}}
+	if _, err := detached.Serialize(); err == nil {
+		t.Error("expected an error for a detached node")
+	}
+
+	bad := &SerializedOffsetMap{Spans: []SerializedSpan{{Start: 0, End: 2, Path: "#main", Child: 0}}}
+	if _, err := bad.Resolve(loadString(t, provenancePage)); err == nil {
+		t.Error("expected an error when the child is not a text node")
+	}
+}
+
+func checkSpans(t *testing.T, out string, m *OffsetMap) {
+	t.Helper()
+	space := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ", "\f", " ")
+	for _, sp := range m.Spans {
+		if want := space.Replace(sp.Node.Data[sp.Offset : sp.Offset+sp.End-sp.Start]); space.Replace(out[sp.Start:sp.End]) != want {
+			t.Errorf("span %d-%d: expected %q, got %q", sp.Start, sp.End, want, out[sp.Start:sp.End])
+		}
+	}
+}
+
+func TestHtmlWithOffsets(t *testing.T) {
+	d := loadString(t, `<html><body><div id="main"><p>Fish &amp; chips</p>
+	<p class="x">Second
+	line.</p><script>if (a < b) {}</script></div></body></html>`)
+	main := d.Find("#main")
+
+	out, m, err := main.HtmlWithOffsets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := main.Html(); out != want {
+		t.Errorf("expected the same output as Html:\n%s\n%s", want, out)
+	}
+	checkSpans(t, out, m)
+	i := strings.Index(out, "chips")
+	if sp := m.Locate(i, i+5); len(sp) != 1 || sp[0].Node.Data != "Fish & chips" || sp[0].Offset != 7 {
+		t.Errorf("unexpected span for escaped text %+v", sp)
+	}
+
+	outer, om, err := main.OuterHtmlWithOffsets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := OuterHtml(main); outer != want {
+		t.Errorf("expected the same output as OuterHtml:\n%s\n%s", want, outer)
+	}
+	checkSpans(t, outer, om)
+
+	var buf bytes.Buffer
+	pm, err := RenderSelectionWithOffsets(&buf, d.Find("p"), &SelectionRenderOptions{Separator: "\n", Render: MinifyRenderOptions()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	minified := buf.String()
+	if strings.Contains(minified, "Second\n") {
+		t.Errorf("expected collapsed whitespace: %q", minified)
+	}
+	checkSpans(t, minified, pm)
+	i = strings.Index(minified, "line.")
+	if sp := pm.Locate(i, i+5); len(sp) != 1 || sp[0].Offset != strings.Index(sp[0].Node.Data, "line.") {
+		t.Errorf("unexpected span after collapsing whitespace %+v", sp)
+	}
+}
+
+func TestOffsetMapSlice(t *testing.T) {
+	d := loadString(t, provenancePage)
+	text, m := d.Find("#main").TextWithOffsets()
+
+	start, end := 6, 24
+	chunk := m.Slice(start, end)
+	if chunk.Len() != end-start || chunk.Spans[0].Start != 0 {
+		t.Fatalf("unexpected chunk spans %+v", chunk.Spans)
+	}
+	checkSpans(t, text[start:end], chunk)
+	if len(m.Spans) != 4 || m.Spans[0].Start != 0 {
+		t.Error("slicing must not modify the original map")
+	}
+}
@@ -3,7 +3,7 @@ package launder
 import "golang.org/x/net/html"
 
 func (s *Selection) Is(selector string) bool {
-	return s.IsMatcher(compileMatcher(selector))
+	return s.IsMatcher(s.compile(selector))
 }
 
 func (s *Selection) IsMatcher(m Matcher) bool {
@@ -0,0 +1,562 @@
+package launder
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type RenderOptions struct {
+	Indent         string
+	Minify         bool
+	SortAttributes bool
+	XHTML          bool
+}
+
+func PrettyRenderOptions() *RenderOptions {
+	return &RenderOptions{Indent: "  ", SortAttributes: true}
+}
+
+func MinifyRenderOptions() *RenderOptions {
+	return &RenderOptions{Minify: true, SortAttributes: true}
+}
+
+func RenderWithOptions(w io.Writer, s *Selection, opts *RenderOptions) error {
+	if s.Length() == 0 {
+		return nil
+	}
+	return renderNode(w, s.Get(0), opts)
+}
+
+func OuterHtmlWithOptions(s *Selection, opts *RenderOptions) (string, error) {
+	var buf bytes.Buffer
+	if err := RenderWithOptions(&buf, s, opts); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type SelectionRenderOptions struct {
+	Inner     bool
+	Separator string
+	Render    *RenderOptions
+}
+
+func RenderSelection(w io.Writer, s *Selection, opts *SelectionRenderOptions) error {
+	return renderSelection(w, s, opts, nil)
+}
+
+func RenderSelectionWithOffsets(w io.Writer, s *Selection, opts *SelectionRenderOptions) (*OffsetMap, error) {
+	m := &OffsetMap{}
+	if err := renderSelection(w, s, opts, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func renderSelection(w io.Writer, s *Selection, opts *SelectionRenderOptions, m *OffsetMap) error {
+	if opts == nil {
+		opts = &SelectionRenderOptions{}
+	}
+	ro := opts.Render
+	if ro == nil {
+		ro = &RenderOptions{}
+	}
+	bw := bufio.NewWriter(w)
+	r := &renderer{w: bw, opts: ro, offsets: m}
+	for i, n := range s.Nodes {
+		if i > 0 {
+			r.write(opts.Separator)
+			r.written = false
+		}
+		if opts.Inner {
+			r.inner(n)
+		} else {
+			r.node(n, 0, false)
+		}
+	}
+	return bw.Flush()
+}
+
+func renderNode(w io.Writer, n *html.Node, opts *RenderOptions) error {
+	if opts == nil {
+		opts = &RenderOptions{}
+	}
+	bw := bufio.NewWriter(w)
+	r := &renderer{w: bw, opts: opts}
+	r.node(n, 0, false)
+	return bw.Flush()
+}
+
+var rawTextElements = map[atom.Atom]bool{
+	atom.Iframe: true, atom.Noembed: true, atom.Noframes: true, atom.Noscript: true,
+	atom.Plaintext: true, atom.Script: true, atom.Style: true, atom.Xmp: true,
+}
+
+var preserveSpaceElements = map[atom.Atom]bool{
+	atom.Pre: true, atom.Textarea: true, atom.Listing: true, atom.Plaintext: true,
+}
+
+var blockElements = map[atom.Atom]bool{
+	atom.Html: true, atom.Head: true, atom.Body: true, atom.Title: true, atom.Meta: true,
+	atom.Link: true, atom.Base: true, atom.Style: true, atom.Script: true, atom.Noscript: true,
+	atom.Template: true, atom.Address: true, atom.Article: true, atom.Aside: true,
+	atom.Blockquote: true, atom.Details: true, atom.Dialog: true, atom.Summary: true,
+	atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Dd: true, atom.Fieldset: true,
+	atom.Legend: true, atom.Figcaption: true, atom.Figure: true, atom.Footer: true,
+	atom.Form: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.Header: true, atom.Hgroup: true, atom.Hr: true,
+	atom.Li: true, atom.Main: true, atom.Menu: true, atom.Nav: true, atom.Ol: true,
+	atom.Ul: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Caption: true, atom.Colgroup: true, atom.Col: true, atom.Thead: true,
+	atom.Tbody: true, atom.Tfoot: true, atom.Tr: true, atom.Td: true, atom.Th: true,
+	atom.Optgroup: true, atom.Option: true, atom.Frameset: true, atom.Frame: true,
+}
+
+var xmlNamespaces = map[string]string{
+	"html": "http://www.w3.org/1999/xhtml",
+	"svg":  "http://www.w3.org/2000/svg",
+	"math": "http://www.w3.org/1998/Math/MathML",
+}
+
+type renderer struct {
+	w       *bufio.Writer
+	opts    *RenderOptions
+	written bool
+	offsets *OffsetMap
+	pos     int
+}
+
+func (r *renderer) write(s string) {
+	r.written = true
+	r.w.WriteString(s)
+	r.pos += len(s)
+}
+
+var textEscapes = map[byte]string{
+	'&':  "&amp;",
+	'\'': "&#39;",
+	'<':  "&lt;",
+	'>':  "&gt;",
+	'"':  "&#34;",
+	'\r': "&#13;",
+}
+
+func (r *renderer) writeText(n *html.Node, s string, src []int, escape bool) {
+	if r.offsets == nil {
+		if escape {
+			s = html.EscapeString(s)
+		}
+		r.write(s)
+		return
+	}
+	r.written = true
+	for i := 0; i < len(s); i++ {
+		if e, ok := textEscapes[s[i]]; ok && escape {
+			r.w.WriteString(e)
+			r.pos += len(e)
+			continue
+		}
+		off := i
+		if src != nil {
+			off = src[i]
+		}
+		r.offsets.add(r.pos, n, off, 1)
+		r.w.WriteByte(s[i])
+		r.pos++
+	}
+}
+
+func (r *renderer) pretty() bool {
+	return r.opts.Indent != ""
+}
+
+func (r *renderer) newline(depth int) {
+	if r.written {
+		r.w.WriteByte('\n')
+		r.pos++
+	}
+	r.write(strings.Repeat(r.opts.Indent, depth))
+}
+
+func isBlock(n *html.Node) bool {
+	return n != nil && (n.Type == html.DocumentNode || n.Type == html.ElementNode && n.Namespace == "" && blockElements[n.DataAtom])
+}
+
+func (r *renderer) dropped(n *html.Node) bool {
+	return n.Type == html.CommentNode && r.opts.Minify && !strings.HasPrefix(n.Data, "[if")
+}
+
+func (r *renderer) sibling(n *html.Node, next bool) *html.Node {
+	for {
+		if next {
+			n = n.NextSibling
+		} else {
+			n = n.PrevSibling
+		}
+		if n == nil || !r.dropped(n) {
+			return n
+		}
+	}
+}
+
+func (r *renderer) breaksLine(n *html.Node, next bool) bool {
+	s := r.sibling(n, next)
+	if s == nil {
+		return isBlock(n.Parent)
+	}
+	return isBlock(s) || s.Type == html.CommentNode && r.pretty()
+}
+
+func (r *renderer) node(n *html.Node, depth int, preserve bool) {
+	switch n.Type {
+	case html.DocumentNode:
+		r.children(n, depth, preserve)
+	case html.DoctypeNode:
+		r.doctype(n)
+	case html.CommentNode:
+		if !r.dropped(n) {
+			r.write("<!--" + n.Data + "-->")
+		}
+	case html.TextNode:
+		r.text(n, preserve)
+	case html.ElementNode:
+		r.element(n, depth, preserve)
+	case html.RawNode:
+		r.write(n.Data)
+	}
+}
+
+func (r *renderer) doctype(n *html.Node) {
+	r.write("<!DOCTYPE " + n.Data)
+	var public, system string
+	for _, a := range n.Attr {
+		switch a.Key {
+		case "public":
+			public = a.Val
+		case "system":
+			system = a.Val
+		}
+	}
+	switch {
+	case public != "":
+		r.write(" PUBLIC " + quoteDoctype(public))
+		if system != "" {
+			r.write(" " + quoteDoctype(system))
+		}
+	case system != "":
+		r.write(" SYSTEM " + quoteDoctype(system))
+	}
+	r.write(">")
+}
+
+func quoteDoctype(s string) string {
+	if strings.Contains(s, `"`) {
+		return "'" + s + "'"
+	}
+	return `"` + s + `"`
+}
+
+func (r *renderer) text(n *html.Node, preserve bool) {
+	if p := n.Parent; p != nil && p.Type == html.ElementNode && rawTextElements[p.DataAtom] {
+		if r.opts.XHTML && strings.ContainsAny(n.Data, "<&") {
+			r.write("<![CDATA[")
+			r.writeText(n, n.Data, nil, false)
+			r.write("]]>")
+		} else {
+			r.writeText(n, n.Data, nil, false)
+		}
+		return
+	}
+	if preserve || !r.opts.Minify && !r.pretty() {
+		r.writeText(n, n.Data, nil, true)
+		return
+	}
+
+	s, src := collapseWhitespace(n.Data)
+	if r.breaksLine(n, false) {
+		trimmed := strings.TrimLeft(s, " ")
+		src = src[len(s)-len(trimmed):]
+		s = trimmed
+	}
+	if r.breaksLine(n, true) {
+		s = strings.TrimRight(s, " ")
+		src = src[:len(s)]
+	}
+	if s != "" {
+		r.writeText(n, s, src, true)
+	}
+}
+
+func collapseWhitespace(s string) (string, []int) {
+	var b strings.Builder
+	src := make([]int, 0, len(s))
+	space := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case ' ', '\t', '\n', '\r', '\f':
+			if !space {
+				b.WriteByte(' ')
+				src = append(src, i)
+			}
+			space = true
+		default:
+			b.WriteByte(s[i])
+			src = append(src, i)
+			space = false
+		}
+	}
+	return b.String(), src
+}
+
+func (r *renderer) element(n *html.Node, depth int, preserve bool) {
+	if !r.omitStartTag(n) {
+		r.startTag(n)
+	}
+	if isVoidElement(n) {
+		return
+	}
+	if r.opts.XHTML && n.Namespace != "" && n.FirstChild == nil {
+		return
+	}
+
+	if n.Namespace == "" && preserveSpaceElements[n.DataAtom] {
+		preserve = true
+		if c := n.FirstChild; c != nil && c.Type == html.TextNode && strings.HasPrefix(c.Data, "\n") {
+			r.write("\n")
+		}
+	}
+	r.children(n, depth+1, preserve)
+
+	if !r.omitEndTag(n) {
+		if r.pretty() && !preserve && r.hasBlockChild(n) {
+			r.newline(depth)
+		}
+		r.write("</" + n.Data + ">")
+	}
+}
+
+func (r *renderer) inner(n *html.Node) {
+	preserve := n.Type == html.ElementNode && n.Namespace == "" && preserveSpaceElements[n.DataAtom]
+	r.children(n, 0, preserve)
+}
+
+func (r *renderer) children(n *html.Node, depth int, preserve bool) {
+	if preserve || !r.pretty() || !r.hasBlockChild(n) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			r.node(c, depth, preserve)
+		}
+		return
+	}
+
+	line := false
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if r.dropped(c) {
+			continue
+		}
+		if isBlock(c) || c.Type != html.TextNode && c.Type != html.ElementNode {
+			r.newline(depth)
+			r.node(c, depth, false)
+			line = false
+			continue
+		}
+		if c.Type == html.TextNode && strings.TrimSpace(c.Data) == "" && (!line || r.breaksLine(c, true)) {
+			continue
+		}
+		if !line {
+			r.newline(depth)
+			line = true
+		}
+		r.node(c, depth, false)
+	}
+}
+
+func (r *renderer) hasBlockChild(n *html.Node) bool {
+	if n.Type == html.ElementNode && rawTextElements[n.DataAtom] {
+		return false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isBlock(c) || c.Type == html.DoctypeNode {
+			return true
+		}
+	}
+	return false
+}
+
+func isVoidElement(n *html.Node) bool {
+	return n.Namespace == "" && voidElements[n.DataAtom]
+}
+
+func (r *renderer) startTag(n *html.Node) {
+	r.write("<" + n.Data)
+
+	attrs := n.Attr
+	if r.opts.SortAttributes {
+		attrs = append([]html.Attribute(nil), attrs...)
+		sort.SliceStable(attrs, func(i, j int) bool {
+			if attrs[i].Namespace != attrs[j].Namespace {
+				return attrs[i].Namespace < attrs[j].Namespace
+			}
+			return attrs[i].Key < attrs[j].Key
+		})
+	}
+	if r.opts.XHTML {
+		attrs = r.xmlnsAttributes(n, attrs)
+	}
+
+	for _, a := range attrs {
+		r.write(" ")
+		if a.Namespace != "" {
+			r.write(a.Namespace + ":")
+		}
+		r.write(a.Key)
+		if a.Val == "" && r.opts.Minify && !r.opts.XHTML {
+			continue
+		}
+		r.write(`="` + html.EscapeString(a.Val) + `"`)
+	}
+
+	switch {
+	case r.opts.XHTML && (isVoidElement(n) || n.Namespace != "" && n.FirstChild == nil):
+		r.write(" />")
+	case isVoidElement(n) && !r.opts.Minify:
+		r.write("/>")
+	default:
+		r.write(">")
+	}
+}
+
+func (r *renderer) xmlnsAttributes(n *html.Node, attrs []html.Attribute) []html.Attribute {
+	ns := n.Namespace
+	if ns == "" {
+		ns = "html"
+	}
+	parent := ""
+	if p := n.Parent; p != nil && p.Type == html.ElementNode {
+		parent = p.Namespace
+		if parent == "" {
+			parent = "html"
+		}
+	}
+	if ns == parent {
+		return attrs
+	}
+
+	var decl []html.Attribute
+	if _, ok := getAttributeValue("xmlns", n); !ok {
+		decl = append(decl, html.Attribute{Key: "xmlns", Val: xmlNamespaces[ns]})
+	}
+	if ns == "svg" && usesXLink(n) {
+		if _, ok := getAttributeValue("xmlns:xlink", n); !ok {
+			decl = append(decl, html.Attribute{Key: "xmlns:xlink", Val: "http://www.w3.org/1999/xlink"})
+		}
+	}
+	return append(decl, attrs...)
+}
+
+func usesXLink(n *html.Node) bool {
+	for _, a := range n.Attr {
+		if a.Namespace == "xlink" {
+			return true
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && usesXLink(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *renderer) omitStartTag(n *html.Node) bool {
+	if !r.opts.Minify || r.opts.XHTML || n.Namespace != "" || len(n.Attr) > 0 {
+		return false
+	}
+	first := n.FirstChild
+	for first != nil && (r.dropped(first) || first.Type == html.TextNode && strings.TrimSpace(first.Data) == "") {
+		first = first.NextSibling
+	}
+
+	switch n.DataAtom {
+	case atom.Html:
+		return first == nil || first.Type != html.CommentNode
+	case atom.Head:
+		return first == nil || first.Type == html.ElementNode
+	case atom.Body:
+		if first == nil || first.Type == html.TextNode {
+			return true
+		}
+		switch first.DataAtom {
+		case atom.Meta, atom.Link, atom.Script, atom.Style, atom.Template:
+			return false
+		}
+		return first.Type == html.ElementNode
+	}
+	return false
+}
+
+func (r *renderer) omitEndTag(n *html.Node) bool {
+	if !r.opts.Minify || r.opts.XHTML || n.Namespace != "" {
+		return false
+	}
+	next := r.sibling(n, true)
+	for next != nil && next.Type == html.TextNode && strings.TrimSpace(next.Data) == "" {
+		next = r.sibling(next, true)
+	}
+	is := func(atoms ...atom.Atom) bool {
+		if next == nil || next.Type != html.ElementNode {
+			return false
+		}
+		for _, a := range atoms {
+			if next.DataAtom == a {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch n.DataAtom {
+	case atom.Html, atom.Head, atom.Body:
+		return true
+	case atom.Li:
+		return next == nil || is(atom.Li)
+	case atom.Dt:
+		return is(atom.Dt, atom.Dd)
+	case atom.Dd:
+		return next == nil || is(atom.Dt, atom.Dd)
+	case atom.Rt, atom.Rp:
+		return next == nil || is(atom.Rt, atom.Rp)
+	case atom.Option:
+		return next == nil || is(atom.Option, atom.Optgroup)
+	case atom.Optgroup:
+		return next == nil || is(atom.Optgroup)
+	case atom.Thead:
+		return is(atom.Tbody, atom.Tfoot)
+	case atom.Tbody:
+		return next == nil || is(atom.Tbody, atom.Tfoot)
+	case atom.Tfoot:
+		return next == nil
+	case atom.Tr:
+		return next == nil || is(atom.Tr)
+	case atom.Td, atom.Th:
+		return next == nil || is(atom.Td, atom.Th)
+	case atom.P:
+		if next == nil {
+			if n.Parent == nil {
+				return false
+			}
+			switch n.Parent.DataAtom {
+			case atom.A, atom.Audio, atom.Del, atom.Ins, atom.Map, atom.Noscript, atom.Video:
+				return false
+			}
+			return n.Parent.Type == html.ElementNode
+		}
+		return next.Type == html.ElementNode && next.Namespace == "" && closesParagraph[next.DataAtom]
+	}
+	return false
+}
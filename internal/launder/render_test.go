@@ -0,0 +1,105 @@
+package launder
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const renderPage = `<!DOCTYPE html><html><head><title> T </title><meta charset=utf-8></head><body>
+<!-- note -->
+<div id=x class=y>
+  <p>Hello <b>big</b>
+  world</p><p>two</p>
+<ul><li>a</li><li>b <i>c</i></li></ul><pre>
+  keep
+   this</pre><table><tr><td>1</td><td>2</td></tr></table><input disabled><svg><path d='M0'/></svg></div></body></html>`
+
+func TestRenderWithOptionsDefault(t *testing.T) {
+	for _, d := range []*Document{Doc(), Doc2(), Doc3(), DocB(), DocW()} {
+		want, err := OuterHtml(d.Selection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := OuterHtmlWithOptions(d.Selection, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("default options differ from html.Render")
+		}
+	}
+}
+
+func TestRenderPretty(t *testing.T) {
+	d := loadString(t, renderPage)
+	got, err := OuterHtmlWithOptions(d.Find("div"), PrettyRenderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<div class="y" id="x">
+  <p>Hello <b>big</b> world</p>
+  <p>two</p>
+  <ul>
+    <li>a</li>
+    <li>b <i>c</i></li>
+  </ul>
+  <pre>  keep
+   this</pre>
+  <table>
+    <tbody>
+      <tr>
+        <td>1</td>
+        <td>2</td>
+      </tr>
+    </tbody>
+  </table>
+  <input disabled=""/><svg><path d="M0"></path></svg>
+</div>`
+	if got != want {
+		t.Errorf("unexpected pretty output:\n%s", got)
+	}
+}
+
+func TestRenderMinify(t *testing.T) {
+	d := loadString(t, renderPage)
+	got, err := OuterHtmlWithOptions(d.Selection, MinifyRenderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<!DOCTYPE html><title>T</title><meta charset="utf-8"><div class="y" id="x"><p>Hello <b>big</b> world<p>two<ul><li>a<li>b <i>c</i></ul><pre>  keep
+   this</pre><table><tbody><tr><td>1<td>2</table><input disabled><svg><path d="M0"></path></svg></div>`
+	if got != want {
+		t.Errorf("unexpected minified output:\n%s", got)
+	}
+
+	re := loadString(t, got)
+	var names func(n *html.Node, b *strings.Builder)
+	names = func(n *html.Node, b *strings.Builder) {
+		if n.Type == html.ElementNode {
+			b.WriteString(n.Data + " ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			names(c, b)
+		}
+	}
+	var a, b strings.Builder
+	names(d.rootNode, &a)
+	names(re.rootNode, &b)
+	if a.String() != b.String() {
+		t.Errorf("minified output does not reparse to the same tree:\n%s\n%s", a.String(), b.String())
+	}
+}
+
+func TestRenderXHTML(t *testing.T) {
+	d := loadString(t, `<p b="2" a="1">x<br>y</p><svg><use xlink:href="#a"/></svg><script>if (a < b) {}</script>`)
+	got, err := OuterHtmlWithOptions(d.Find("body"), &RenderOptions{XHTML: true, SortAttributes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<body><p a="1" b="2">x<br />y</p><svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#a" /></svg><script><![CDATA[if (a < b) {}]]></script></body>`
+	if got != want {
+		t.Errorf("unexpected XHTML output:\n%s", got)
+	}
+}
@@ -0,0 +1,93 @@
+package launder
+
+import (
+	"errors"
+
+	"golang.org/x/net/html"
+)
+
+var ErrReadOnly = errors.New("document is a read-only snapshot")
+
+func (d *Document) Snapshot() *Document {
+	if d.readOnly {
+		return d
+	}
+	snap := CloneDocument(d)
+	snap.readOnly = true
+	return snap
+}
+
+func (d *Document) ReadOnly() bool {
+	return d.readOnly
+}
+
+func (d *Document) WorkingCopy() *Document {
+	if !d.readOnly {
+		return d
+	}
+	cp, _ := d.thaw()
+	return cp
+}
+
+func (s *Selection) Document() *Document {
+	return s.document
+}
+
+func (d *Document) thaw() (*Document, map[*html.Node]*html.Node) {
+	cp := CloneDocument(d)
+	m := make(map[*html.Node]*html.Node)
+	pairNodes(d.rootNode, cp.rootNode, m)
+	return cp, m
+}
+
+func pairNodes(src, dst *html.Node, m map[*html.Node]*html.Node) {
+	m[src] = dst
+	for s, c := src.FirstChild, dst.FirstChild; s != nil && c != nil; s, c = s.NextSibling, c.NextSibling {
+		pairNodes(s, c, m)
+	}
+}
+
+func (s *Selection) writable() (*Selection, map[*html.Node]*html.Node) {
+	if s.document == nil {
+		return s, nil
+	}
+	if !s.document.readOnly {
+		s.document.touch()
+		return s, nil
+	}
+	d, m := s.document.thaw()
+	d.touch()
+	return &Selection{thawNodes(s.Nodes, m), d, s.prevSel}, m
+}
+
+func thawNodes(ns []*html.Node, m map[*html.Node]*html.Node) []*html.Node {
+	if m == nil {
+		return ns
+	}
+	result := make([]*html.Node, len(ns))
+	for i, n := range ns {
+		if c, ok := m[n]; ok {
+			result[i] = c
+		} else {
+			result[i] = n
+		}
+	}
+	return result
+}
+
+func (s *Selection) adopt(sel *Selection, m map[*html.Node]*html.Node) *Selection {
+	if sel.document == nil || !sel.document.readOnly {
+		return sel
+	}
+	ns := newEmptySelection(s.document)
+	for _, n := range sel.Nodes {
+		if c, ok := m[n]; ok {
+			ns.Nodes = append(ns.Nodes, c)
+			continue
+		}
+		c := cloneNode(n)
+		s.document.importData(sel.document, n, c)
+		ns.Nodes = append(ns.Nodes, c)
+	}
+	return ns
+}
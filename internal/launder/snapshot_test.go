@@ -0,0 +1,230 @@
+package launder
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const snapshotPage = `<html><head><title>Snap</title></head><body><div id="main"><p class="a" id="one">one <a href="/x">link</a></p><p id="two" style="display:none">two</p><ul><li>1<li>2<li>3</ul></div></body></html>`
+
+func TestSnapshotCopyOnWrite(t *testing.T) {
+	d := loadString(t, snapshotPage)
+	d.Find("#one").SetData("score", 3)
+	snap := d.Snapshot()
+	if !snap.ReadOnly() || d.ReadOnly() {
+		t.Fatal("only the snapshot should be read-only")
+	}
+	if snap.Snapshot() != snap {
+		t.Error("snapshot of a snapshot should be itself")
+	}
+	before, _ := snap.Html()
+
+	sel := snap.Find("#one").SetAttr("title", "t").AddClass("b")
+	if sel.Document() == snap || sel.Document().ReadOnly() {
+		t.Fatal("mutation should return a writable copy")
+	}
+	if v, _ := sel.Attr("title"); v != "t" || !sel.HasClass("b") {
+		t.Errorf("copy not modified: %v", v)
+	}
+	if v, ok := sel.Data("score"); !ok || v != 3 {
+		t.Errorf("copy lost annotations: %v", v)
+	}
+
+	moved := snap.Find("ul").AppendSelection(snap.Find("#two"))
+	if moved.Document().Find("ul > #two").Length() != 1 || moved.Document().Find("#two").Length() != 1 {
+		t.Error("expected #two to be moved inside the copy")
+	}
+	wrapped := snap.Find("#two").WrapSelection(snap.Find("a"))
+	if wrapped.Document().Find("a > #two").Length() != 1 || wrapped.Document().Find("#one > a").Length() != 1 {
+		t.Error("expected #two to be wrapped in a copy of the link")
+	}
+	replaced := snap.Find("#two").ReplaceWithSelection(snap.Find("#one"))
+	if doc := replaced.Document(); doc.Find("#two").Length() != 0 || doc.Find("#one").Length() != 1 || doc.Find("#one + ul").Length() != 1 {
+		t.Error("expected #one to replace #two inside the copy")
+	}
+	snap.Find("li").Remove()
+	snap.Find("body").StripHidden()
+	snap.Find("#one").SetData("score", 4).WrapHtml("<section></section>")
+	snap.Find("a").ResolveURLs(d.Url)
+
+	clone := snap.Find("#one").Clone()
+	if clone.Document() == snap || clone.Document().ReadOnly() {
+		t.Error("clone of a snapshot should be owned by a writable document")
+	}
+	d.Find("ul").AppendSelection(snap.Find("li"))
+	if d.Find("li").Length() != 6 {
+		t.Errorf("expected snapshot nodes to be copied, got %d", d.Find("li").Length())
+	}
+
+	if after, _ := snap.Html(); after != before {
+		t.Errorf("snapshot modified:\n%s\n%s", before, after)
+	}
+	if v, _ := snap.Find("#one").Data("score"); v != 3 {
+		t.Errorf("snapshot annotations modified: %v", v)
+	}
+
+	if err := Diff(snap, d).Apply(snap); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if _, err := snap.DetectInjections(&InjectionOptions{Strip: true}); err != ErrReadOnly {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if _, err := snap.DetectInjections(nil); err != nil {
+		t.Errorf("detection without stripping must work on a snapshot: %v", err)
+	}
+}
+
+func TestSnapshotConcurrentReads(t *testing.T) {
+	d := loadString(t, snapshotPage)
+	d.Find("li").SetData("rank", 1)
+	snap := d.Snapshot()
+	want, _ := snap.Html()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if n := snap.Find("li:data(rank=1)").Length(); n != 3 {
+					t.Errorf("expected 3 annotated items, got %d", n)
+				}
+				snap.Find("p").Text()
+				snap.Find("#two").IsVisible()
+				snap.Find("a").AccessibleName()
+				snap.AccessibilityTree()
+				var buf bytes.Buffer
+				if err := RenderWithOptions(&buf, snap.Selection, PrettyRenderOptions()); err != nil {
+					t.Error(err)
+				}
+				if _, err := snap.MarshalJSON(); err != nil {
+					t.Error(err)
+				}
+				if _, err := snap.DetectInjections(nil); err != nil {
+					t.Error(err)
+				}
+				Diff(snap, snap)
+
+				own := CloneDocument(snap)
+				own.Find("li").SetAttr("data-i", "x").SetData("rank", i).Remove()
+				if own.Find("li").Length() != 0 || snap.Find("li").Length() != 3 {
+					t.Error("expected edits to stay in the private copy")
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got, _ := snap.Html(); got != want {
+		t.Error("snapshot modified by concurrent stages")
+	}
+}
+
+func TestSnapshotWorkingCopy(t *testing.T) {
+	d := loadString(t, snapshotPage)
+	snap := d.Snapshot()
+
+	seen := make(map[*Document]bool)
+	snap.Find("li").Each(func(i int, s *Selection) {
+		doc := s.SetAttr("data-n", strconv.Itoa(i)).Document()
+		if doc == snap || doc.ReadOnly() || seen[doc] {
+			t.Fatal("expected every mutation of a snapshot to get its own writable copy")
+		}
+		seen[doc] = true
+		if got := doc.Find("[data-n]").Length(); got != 1 {
+			t.Errorf("expected only this mutation in its copy, found %d edited items", got)
+		}
+	})
+
+	chain := snap.Find("p").AddClass("seen").SetAttr("data-seen", "1")
+	if chain.Document().Find("p.seen[data-seen]").Length() != 2 {
+		t.Error("expected chained mutations to stay in one copy")
+	}
+
+	work := snap.WorkingCopy()
+	if work == snap || work.ReadOnly() || work == snap.WorkingCopy() {
+		t.Fatal("expected a new writable working copy")
+	}
+	work.Find("li").Each(func(i int, s *Selection) {
+		s.SetAttr("data-n", strconv.Itoa(i))
+	})
+	if got := work.Find("li").Map(func(_ int, s *Selection) string { return s.AttrOr("data-n", "") }); strings.Join(got, ",") != "0,1,2" {
+		t.Errorf("unexpected working copy attributes %v", got)
+	}
+	if snap.Find("[data-n], .seen").Length() != 0 {
+		t.Error("snapshot modified")
+	}
+	if d.WorkingCopy() != d {
+		t.Error("a writable document is its own working copy")
+	}
+}
+
+func TestSnapshotConcurrentWrites(t *testing.T) {
+	d := loadString(t, snapshotPage)
+	d.Find("li").SetData("rank", 1)
+	snap := d.Snapshot()
+	want, _ := snap.Html()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v := strconv.Itoa(i)
+			for j := 0; j < 20; j++ {
+				items := snap.Find("li").SetAttr("data-i", v).SetData("rank", i)
+				doc := items.Document()
+				if got := doc.Find("li[data-i=\"" + v + "\"]").Length(); got != 3 {
+					t.Errorf("expected 3 items edited by goroutine %d, got %d", i, got)
+				}
+				if doc.Find("li:data(rank="+v+")").Length() != 3 {
+					t.Errorf("expected annotations from goroutine %d", i)
+				}
+				if doc.Find("[data-i]").Length() != 3 {
+					t.Error("edits from another goroutine leaked into this copy")
+				}
+				snap.Find("#two").Remove()
+				snap.Find("ul").AppendSelection(snap.Find("#one"))
+				snap.Find("p").SetText(v).WrapHtml("<div></div>")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got, _ := snap.Html(); got != want {
+		t.Error("snapshot modified by concurrent mutations")
+	}
+	if snap.Find("li:data(rank=1)").Length() != 3 {
+		t.Error("snapshot annotations modified")
+	}
+}
+
+func TestSnapshotMutationHashes(t *testing.T) {
+	snap := loadString(t, snapshotPage).Snapshot()
+	before := snap.Find("#one").SubtreeHash()
+
+	sel := snap.Find("#one").SetAttr("title", "t")
+	first := sel.SubtreeHash()
+	if first == before {
+		t.Error("expected the mutated copy to hash differently")
+	}
+	sel.SetAttr("title", "u")
+	if sel.SubtreeHash() == first {
+		t.Error("stale subtree hash after a second mutation of the copy")
+	}
+	if snap.Find("#one").SubtreeHash() != before {
+		t.Error("snapshot hash changed")
+	}
+
+	h := NewSubtreeHasher(nil)
+	work := snap.Find("ul").SetAttr("class", "list")
+	hashes := work.SubtreeHashes(h)
+	work.Find("li").First().Remove()
+	if work.SubtreeHashes(h)[0] == hashes[0] {
+		t.Error("stale hash from a shared hasher after mutating the copy")
+	}
+}
@@ -0,0 +1,201 @@
+package launder
+
+import (
+	"io"
+
+	"github.com/geistblitz/boringformat/internal/launder/parser"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var voidElements = map[atom.Atom]bool{
+	atom.Area: true, atom.Base: true, atom.Br: true, atom.Col: true, atom.Embed: true,
+	atom.Hr: true, atom.Img: true, atom.Input: true, atom.Keygen: true, atom.Link: true,
+	atom.Meta: true, atom.Param: true, atom.Source: true, atom.Track: true, atom.Wbr: true,
+}
+
+var closesParagraph = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Details: true, atom.Div: true, atom.Dl: true, atom.Fieldset: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Main: true, atom.Nav: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true, atom.Ul: true,
+}
+
+type StreamReader struct {
+	z       *html.Tokenizer
+	m       parser.Matcher
+	stack   []*html.Node
+	capture int
+	pending *html.Node
+	err     error
+}
+
+func NewStreamReader(r io.Reader, selector string) (*StreamReader, error) {
+	group, err := parser.ParseGroup(selector)
+	if err != nil {
+		return nil, err
+	}
+	return NewStreamReaderMatcher(r, group)
+}
+
+func NewStreamReaderMatcher(r io.Reader, m parser.Matcher) (*StreamReader, error) {
+	if err := parser.Streamable(m); err != nil {
+		return nil, err
+	}
+	dr, _, err := decodeReader(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return &StreamReader{
+		z:       html.NewTokenizer(dr),
+		m:       m,
+		stack:   []*html.Node{{Type: html.DocumentNode}},
+		capture: -1,
+	}, nil
+}
+
+func (s *StreamReader) Next() (*Selection, error) {
+	for s.pending == nil && s.err == nil {
+		s.step()
+	}
+	if n := s.pending; n != nil {
+		s.pending = nil
+		return s.selection(n), nil
+	}
+	return nil, s.err
+}
+
+func (s *StreamReader) Each(f func(*Selection) error) error {
+	for {
+		sel, err := s.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := f(sel); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *StreamReader) selection(n *html.Node) *Selection {
+	n.Parent = nil
+	root := &html.Node{Type: html.DocumentNode}
+	root.AppendChild(n)
+	return newSingleSelection(n, newDocument(root, nil))
+}
+
+func (s *StreamReader) step() {
+	tt := s.z.Next()
+	switch tt {
+	case html.ErrorToken:
+		s.err = s.z.Err()
+		if s.capture >= 0 {
+			s.pending = s.stack[s.capture]
+			s.capture = -1
+		}
+	case html.StartTagToken, html.SelfClosingTagToken:
+		tok := s.z.Token()
+		s.implicitClose(tok.DataAtom)
+		s.open(tok, tt == html.SelfClosingTagToken || voidElements[tok.DataAtom])
+	case html.EndTagToken:
+		tok := s.z.Token()
+		for i := len(s.stack) - 1; i > 0; i-- {
+			if s.stack[i].Data == tok.Data {
+				s.closeTo(i)
+				break
+			}
+		}
+	case html.TextToken, html.CommentToken:
+		if s.capture >= 0 {
+			tok := s.z.Token()
+			t := html.TextNode
+			if tt == html.CommentToken {
+				t = html.CommentNode
+			}
+			s.top().AppendChild(&html.Node{Type: t, Data: tok.Data})
+		}
+	}
+}
+
+func (s *StreamReader) top() *html.Node {
+	return s.stack[len(s.stack)-1]
+}
+
+func (s *StreamReader) open(tok html.Token, void bool) {
+	parent := s.top()
+	n := &html.Node{
+		Type:     html.ElementNode,
+		DataAtom: tok.DataAtom,
+		Data:     tok.Data,
+		Attr:     tok.Attr,
+	}
+	switch {
+	case tok.DataAtom == atom.Svg || tok.DataAtom == atom.Math:
+		n.Namespace = tok.Data
+	case parent.Namespace != "" && parent.DataAtom != atom.ForeignObject:
+		n.Namespace = parent.Namespace
+	}
+
+	if s.capture >= 0 {
+		parent.AppendChild(n)
+	} else {
+		n.Parent = parent
+	}
+
+	if void {
+		if s.capture < 0 && s.m.Match(n) {
+			s.pending = n
+		}
+		return
+	}
+	s.stack = append(s.stack, n)
+	if s.capture < 0 && s.m.Match(n) {
+		s.capture = len(s.stack) - 1
+	}
+}
+
+func (s *StreamReader) closeTo(i int) {
+	if s.capture >= i {
+		s.pending = s.stack[s.capture]
+		s.capture = -1
+	}
+	s.stack = s.stack[:i]
+}
+
+func (s *StreamReader) implicitClose(a atom.Atom) {
+	switch {
+	case a == atom.Li:
+		s.closeOpen(atom.Ul, atom.Ol, atom.Li)
+	case a == atom.Dt || a == atom.Dd:
+		s.closeOpen(atom.Dl, atom.Dt, atom.Dd)
+	case a == atom.Tr:
+		s.closeOpen(atom.Table, atom.Tr)
+	case a == atom.Td || a == atom.Th:
+		s.closeOpen(atom.Tr, atom.Td, atom.Th)
+	case a == atom.Option:
+		s.closeOpen(atom.Select, atom.Option)
+	}
+	if closesParagraph[a] {
+		s.closeOpen(atom.Button, atom.P)
+	}
+}
+
+func (s *StreamReader) closeOpen(scope atom.Atom, targets ...atom.Atom) {
+	for i := len(s.stack) - 1; i > 0; i-- {
+		a := s.stack[i].DataAtom
+		if a == scope {
+			return
+		}
+		for _, t := range targets {
+			if a == t {
+				s.closeTo(i)
+				return
+			}
+		}
+	}
+}
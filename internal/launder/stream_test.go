@@ -0,0 +1,83 @@
+package launder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const streamPage = `<!DOCTYPE html>
+<html><body>
+<div class="thread">
+  <article class="post" id="p1"><h2>First</h2><p>one<p>two</article>
+  <aside><article class="post" id="p2"><h2>Aside</h2></article></aside>
+  <article class="post" id="p3"><h2>Third</h2><img src="a.png"><ul><li>a<li>b</ul></article>
+</div>
+<article class="post" id="p4"><h2>Outside</h2></article>
+</body></html>`
+
+func TestStreamReader(t *testing.T) {
+	r, err := NewStreamReader(strings.NewReader(streamPage), "div.thread > article.post, img")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	err = r.Each(func(s *Selection) error {
+		if s.document.rootNode.FirstChild != s.Nodes[0] {
+			t.Errorf("selection %s is not standalone", s.AttrOr("id", ""))
+		}
+		ids = append(ids, s.AttrOr("id", ""))
+		switch s.AttrOr("id", "") {
+		case "p1":
+			if n := s.Find("p").Length(); n != 2 {
+				t.Errorf("expected 2 paragraphs, found %d", n)
+			}
+		case "p3":
+			if n := s.Find("ul > li").Length(); n != 2 {
+				t.Errorf("expected 2 list items, found %d", n)
+			}
+			if n := s.Find("img").Length(); n != 1 {
+				t.Errorf("expected captured image, found %d", n)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(ids, ",") != "p1,p3" {
+		t.Errorf("unexpected matches %v", ids)
+	}
+}
+
+func TestStreamReaderStop(t *testing.T) {
+	stop := errors.New("stop")
+	r, err := NewStreamReader(strings.NewReader(streamPage), "article h2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var titles []string
+	err = r.Each(func(s *Selection) error {
+		titles = append(titles, s.Text())
+		if len(titles) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("expected stop error, got %v", err)
+	}
+	if strings.Join(titles, ",") != "First,Aside" {
+		t.Errorf("unexpected titles %v", titles)
+	}
+}
+
+func TestStreamReaderUnsupported(t *testing.T) {
+	for _, sel := range []string{"h2 + p", "article:has(img)", "li:first-child", "p:contains(one)", "p::before"} {
+		if _, err := NewStreamReader(strings.NewReader(streamPage), sel); err == nil {
+			t.Errorf("expected %q to be rejected", sel)
+		}
+	}
+}
@@ -16,7 +16,7 @@ const (
 )
 
 func (s *Selection) Find(selector string) *Selection {
-	return pushStack(s, findWithMatcher(s.Nodes, compileMatcher(selector)))
+	return pushStack(s, findWithMatcher(s.Nodes, s.compile(selector)))
 }
 
 func (s *Selection) FindMatcher(m Matcher) *Selection {
@@ -59,7 +59,7 @@ func (s *Selection) Children() *Selection {
 }
 
 func (s *Selection) ChildrenFiltered(selector string) *Selection {
-	return filterAndPush(s, getChildrenNodes(s.Nodes, siblingAll), compileMatcher(selector))
+	return filterAndPush(s, getChildrenNodes(s.Nodes, siblingAll), s.compile(selector))
 }
 
 func (s *Selection) ChildrenMatcher(m Matcher) *Selection {
@@ -71,7 +71,7 @@ func (s *Selection) Parent() *Selection {
 }
 
 func (s *Selection) ParentFiltered(selector string) *Selection {
-	return filterAndPush(s, getParentNodes(s.Nodes), compileMatcher(selector))
+	return filterAndPush(s, getParentNodes(s.Nodes), s.compile(selector))
 }
 
 func (s *Selection) ParentMatcher(m Matcher) *Selection {
@@ -79,7 +79,7 @@ func (s *Selection) ParentMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) Closest(selector string) *Selection {
-	cs := compileMatcher(selector)
+	cs := s.compile(selector)
 	return s.ClosestMatcher(cs)
 }
 
@@ -121,7 +121,7 @@ func (s *Selection) Parents() *Selection {
 }
 
 func (s *Selection) ParentsFiltered(selector string) *Selection {
-	return filterAndPush(s, getParentsNodes(s.Nodes, nil, nil), compileMatcher(selector))
+	return filterAndPush(s, getParentsNodes(s.Nodes, nil, nil), s.compile(selector))
 }
 
 func (s *Selection) ParentsMatcher(m Matcher) *Selection {
@@ -129,7 +129,7 @@ func (s *Selection) ParentsMatcher(m Matcher) *Selection {
 }
 
 func (s *Selection) ParentsUntil(selector string) *Selection {
-	return pushStack(s, getParentsNodes(s.Nodes, compileMatcher(selector), nil))
+	return pushStack(s, getParentsNodes(s.Nodes, s.compile(selector), nil))
 }
 
 func (s *Selection) ParentsUntilMatcher(m Matcher) *Selection {
@@ -148,7 +148,7 @@ func (s *Selection) ParentsUntilNodes(nodes ...*html.Node) *Selection {
 }
 
 func (s *Selection) ParentsFilteredUntil(filterSelector, untilSelector string) *Selection {
-	return filterAndPush(s, getParentsNodes(s.Nodes, compileMatcher(untilSelector), nil), compileMatcher(filterSelector))
+	return filterAndPush(s, getParentsNodes(s.Nodes, s.compile(untilSelector), nil), s.compile(filterSelector))
 }
 
 func (s *Selection) ParentsFilteredUntilMatcher(filter, until Matcher) *Selection {
@@ -156,7 +156,7 @@ func (s *Selection) ParentsFilteredUntilMatcher(filter, until Matcher) *Selectio
 }
 
 func (s *Selection) ParentsFilteredUntilSelection(filterSelector string, sel *Selection) *Selection {
-	return s.ParentsMatcherUntilSelection(compileMatcher(filterSelector), sel)
+	return s.ParentsMatcherUntilSelection(s.compile(filterSelector), sel)
 }
 
 func (s *Selection) ParentsMatcherUntilSelection(filter Matcher, sel *Selection) *Selection {
@@ -167,7 +167,7 @@ func (s *Selection) ParentsMatcherUntilSelection(filter Matcher, sel *Selection)
 }
 
 func (s *Selection) ParentsFilteredUntilNodes(filterSelector string, nodes ...*html.Node) *Selection {
-	return filterAndPush(s, getParentsNodes(s.Nodes, nil, nodes), compileMatcher(filterSelector))
+	return filterAndPush(s, getParentsNodes(s.Nodes, nil, nodes), s.compile(filterSelector))
 }
 
 func (s *Selection) ParentsMatcherUntilNodes(filter Matcher, nodes ...*html.Node) *Selection {
@@ -179,7 +179,7 @@ func (s *Selection) Siblings() *Selection {
 }
 
 func (s *Selection) SiblingsFiltered(selector string) *Selection {
-	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingAll, nil, nil), compileMatcher(selector))
+	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingAll, nil, nil), s.compile(selector))
 }
 
 func (s *Selection) SiblingsMatcher(m Matcher) *Selection {
@@ -191,7 +191,7 @@ func (s *Selection) Next() *Selection {
 }
 
 func (s *Selection) NextFiltered(selector string) *Selection {
-	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNext, nil, nil), compileMatcher(selector))
+	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNext, nil, nil), s.compile(selector))
 }
 
 func (s *Selection) NextMatcher(m Matcher) *Selection {
@@ -203,7 +203,7 @@ func (s *Selection) NextAll() *Selection {
 }
 
 func (s *Selection) NextAllFiltered(selector string) *Selection {
-	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNextAll, nil, nil), compileMatcher(selector))
+	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNextAll, nil, nil), s.compile(selector))
 }
 
 func (s *Selection) NextAllMatcher(m Matcher) *Selection {
@@ -215,7 +215,7 @@ func (s *Selection) Prev() *Selection {
 }
 
 func (s *Selection) PrevFiltered(selector string) *Selection {
-	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrev, nil, nil), compileMatcher(selector))
+	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrev, nil, nil), s.compile(selector))
 }
 
 func (s *Selection) PrevMatcher(m Matcher) *Selection {
@@ -227,7 +227,7 @@ func (s *Selection) PrevAll() *Selection {
 }
 
 func (s *Selection) PrevAllFiltered(selector string) *Selection {
-	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrevAll, nil, nil), compileMatcher(selector))
+	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrevAll, nil, nil), s.compile(selector))
 }
 
 func (s *Selection) PrevAllMatcher(m Matcher) *Selection {
@@ -236,7 +236,7 @@ func (s *Selection) PrevAllMatcher(m Matcher) *Selection {
 
 func (s *Selection) NextUntil(selector string) *Selection {
 	return pushStack(s, getSiblingNodes(s.Nodes, siblingNextUntil,
-		compileMatcher(selector), nil))
+		s.compile(selector), nil))
 }
 
 func (s *Selection) NextUntilMatcher(m Matcher) *Selection {
@@ -258,7 +258,7 @@ func (s *Selection) NextUntilNodes(nodes ...*html.Node) *Selection {
 
 func (s *Selection) PrevUntil(selector string) *Selection {
 	return pushStack(s, getSiblingNodes(s.Nodes, siblingPrevUntil,
-		compileMatcher(selector), nil))
+		s.compile(selector), nil))
 }
 
 func (s *Selection) PrevUntilMatcher(m Matcher) *Selection {
@@ -280,7 +280,7 @@ func (s *Selection) PrevUntilNodes(nodes ...*html.Node) *Selection {
 
 func (s *Selection) NextFilteredUntil(filterSelector, untilSelector string) *Selection {
 	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNextUntil,
-		compileMatcher(untilSelector), nil), compileMatcher(filterSelector))
+		s.compile(untilSelector), nil), s.compile(filterSelector))
 }
 
 func (s *Selection) NextFilteredUntilMatcher(filter, until Matcher) *Selection {
@@ -289,7 +289,7 @@ func (s *Selection) NextFilteredUntilMatcher(filter, until Matcher) *Selection {
 }
 
 func (s *Selection) NextFilteredUntilSelection(filterSelector string, sel *Selection) *Selection {
-	return s.NextMatcherUntilSelection(compileMatcher(filterSelector), sel)
+	return s.NextMatcherUntilSelection(s.compile(filterSelector), sel)
 }
 
 func (s *Selection) NextMatcherUntilSelection(filter Matcher, sel *Selection) *Selection {
@@ -301,7 +301,7 @@ func (s *Selection) NextMatcherUntilSelection(filter Matcher, sel *Selection) *S
 
 func (s *Selection) NextFilteredUntilNodes(filterSelector string, nodes ...*html.Node) *Selection {
 	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNextUntil,
-		nil, nodes), compileMatcher(filterSelector))
+		nil, nodes), s.compile(filterSelector))
 }
 
 func (s *Selection) NextMatcherUntilNodes(filter Matcher, nodes ...*html.Node) *Selection {
@@ -311,7 +311,7 @@ func (s *Selection) NextMatcherUntilNodes(filter Matcher, nodes ...*html.Node) *
 
 func (s *Selection) PrevFilteredUntil(filterSelector, untilSelector string) *Selection {
 	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrevUntil,
-		compileMatcher(untilSelector), nil), compileMatcher(filterSelector))
+		s.compile(untilSelector), nil), s.compile(filterSelector))
 }
 
 func (s *Selection) PrevFilteredUntilMatcher(filter, until Matcher) *Selection {
@@ -320,7 +320,7 @@ func (s *Selection) PrevFilteredUntilMatcher(filter, until Matcher) *Selection {
 }
 
 func (s *Selection) PrevFilteredUntilSelection(filterSelector string, sel *Selection) *Selection {
-	return s.PrevMatcherUntilSelection(compileMatcher(filterSelector), sel)
+	return s.PrevMatcherUntilSelection(s.compile(filterSelector), sel)
 }
 
 func (s *Selection) PrevMatcherUntilSelection(filter Matcher, sel *Selection) *Selection {
@@ -332,7 +332,7 @@ func (s *Selection) PrevMatcherUntilSelection(filter Matcher, sel *Selection) *S
 
 func (s *Selection) PrevFilteredUntilNodes(filterSelector string, nodes ...*html.Node) *Selection {
 	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrevUntil,
-		nil, nodes), compileMatcher(filterSelector))
+		nil, nodes), s.compile(filterSelector))
 }
 
 func (s *Selection) PrevMatcherUntilNodes(filter Matcher, nodes ...*html.Node) *Selection {
@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 
 	"github.com/geistblitz/boringformat/internal/launder/parser"
 	"golang.org/x/net/html"
@@ -12,8 +13,29 @@ import (
 
 type Document struct {
 	*Selection
-	Url      *url.URL
-	rootNode *html.Node
+	Url       *url.URL
+	Encoding  string
+	rootNode  *html.Node
+	positions map[*html.Node]Position
+	data      map[*html.Node]map[string]interface{}
+	readOnly  bool
+	version   uint64
+	cache     *documentCache
+}
+
+type documentCache struct {
+	mu     sync.Mutex
+	ax     *axIndex
+	hasher *SubtreeHasher
+}
+
+func (d *Document) touch() {
+	d.version++
+	if d.cache != nil {
+		d.cache.mu.Lock()
+		d.cache.ax = nil
+		d.cache.mu.Unlock()
+	}
 }
 
 func NewDocumentFromNode(root *html.Node) *Document {
@@ -29,11 +51,21 @@ func NewDocument(url string) (*Document, error) {
 }
 
 func NewDocumentFromReader(r io.Reader) (*Document, error) {
-	root, e := html.Parse(r)
+	return NewDocumentFromReaderWithContentType(r, "")
+}
+
+func NewDocumentFromReaderWithContentType(r io.Reader, contentType string) (*Document, error) {
+	dr, enc, e := decodeReader(r, contentType)
+	if e != nil {
+		return nil, e
+	}
+	root, e := html.Parse(dr)
 	if e != nil {
 		return nil, e
 	}
-	return newDocument(root, nil), nil
+	d := newDocument(root, nil)
+	d.Encoding = enc
+	return d, nil
 }
 
 func NewDocumentFromResponse(res *http.Response) (*Document, error) {
@@ -45,20 +77,33 @@ func NewDocumentFromResponse(res *http.Response) (*Document, error) {
 		return nil, errors.New("Response.Request is nil")
 	}
 
-	root, e := html.Parse(res.Body)
+	r, enc, e := decodeReader(res.Body, res.Header.Get("Content-Type"))
+	if e != nil {
+		return nil, e
+	}
+	root, e := html.Parse(r)
 	if e != nil {
 		return nil, e
 	}
 
-	return newDocument(root, res.Request.URL), nil
+	d := newDocument(root, res.Request.URL)
+	d.Encoding = enc
+	return d, nil
 }
 
 func CloneDocument(doc *Document) *Document {
-	return newDocument(cloneNode(doc.rootNode), doc.Url)
+	d := newDocument(cloneNode(doc.rootNode), doc.Url)
+	d.Encoding = doc.Encoding
+	if doc.positions != nil {
+		d.positions = make(map[*html.Node]Position, len(doc.positions))
+		copyPositions(doc.positions, d.positions, doc.rootNode, d.rootNode)
+	}
+	d.importData(doc, doc.rootNode, d.rootNode)
+	return d
 }
 
 func newDocument(root *html.Node, url *url.URL) *Document {
-	d := &Document{nil, url, root}
+	d := &Document{nil, url, "", root, nil, nil, false, 0, &documentCache{}}
 	d.Selection = newSingleSelection(root, d)
 	return d
 }
@@ -198,6 +198,34 @@ func TestNewDocumentFromReader(t *testing.T) {
 	}
 }
 
+func TestNewDocumentFromReaderEncoding(t *testing.T) {
+	cases := []struct {
+		src  string
+		ct   string
+		enc  string
+		text string
+	}{
+		{"<meta charset=\"shift_jis\"><p>\x93\xfa\x96\x7b\x8c\xea</p>", "", "shift_jis", "日本語"},
+		{"<p>\xcf\xf0\xe8\xe2\xe5\xf2</p>", "text/html; charset=windows-1251", "windows-1251", "Привет"},
+		{"<meta http-equiv=\"Content-Type\" content=\"text/html; charset=gb2312\"><p>\xc4\xe3\xba\xc3</p>", "", "gbk", "你好"},
+		{"\xef\xbb\xbf<p>h\xc3\xa9llo</p>", "", "utf-8", "héllo"},
+		{"<p>caf\xe9</p>", "", "windows-1252", "café"},
+	}
+
+	for i, c := range cases {
+		d, e := NewDocumentFromReaderWithContentType(strings.NewReader(c.src), c.ct)
+		if e != nil {
+			t.Fatalf("[%d] - %s", i, e)
+		}
+		if d.Encoding != c.enc {
+			t.Errorf("[%d] - expected encoding %s, got %s", i, c.enc, d.Encoding)
+		}
+		if txt := d.Find("p").Text(); txt != c.text {
+			t.Errorf("[%d] - expected %q, got %q", i, c.text, txt)
+		}
+	}
+}
+
 func TestNewDocumentFromResponseNil(t *testing.T) {
 	_, e := NewDocumentFromResponse(nil)
 	if e == nil {
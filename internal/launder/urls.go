@@ -0,0 +1,73 @@
+package launder
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var rewriteAttributes = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"action":     true,
+	"formaction": true,
+	"poster":     true,
+	"data":       true,
+	"cite":       true,
+	"background": true,
+}
+
+func (s *Selection) RewriteURLs(f func(n *html.Node, attr, ref string) string) *Selection {
+	s, _ = s.writable()
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for i, a := range n.Attr {
+				switch {
+				case a.Namespace != "":
+				case rewriteAttributes[a.Key]:
+					n.Attr[i].Val = f(n, a.Key, strings.TrimSpace(a.Val))
+				case a.Key == "srcset":
+					n.Attr[i].Val = rewriteSrcset(a.Val, func(ref string) string { return f(n, a.Key, ref) })
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range s.Nodes {
+		walk(n)
+	}
+	return s
+}
+
+func (s *Selection) ResolveURLs(base *url.URL) *Selection {
+	if base == nil {
+		return s
+	}
+	return s.RewriteURLs(func(n *html.Node, attr, ref string) string {
+		if ref == "" || strings.HasPrefix(ref, "#") {
+			return ref
+		}
+		u, err := url.Parse(ref)
+		if err != nil {
+			return ref
+		}
+		return base.ResolveReference(u).String()
+	})
+}
+
+func rewriteSrcset(v string, f func(string) string) string {
+	candidates := strings.Split(v, ",")
+	for i, c := range candidates {
+		fields := strings.Fields(c)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = f(fields[0])
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
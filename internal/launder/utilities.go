@@ -3,6 +3,8 @@ package launder
 import (
 	"bytes"
 	"io"
+	"strconv"
+	"strings"
 
 	"golang.org/x/net/html"
 )
@@ -39,6 +41,36 @@ func nodeName(node *html.Node) string {
 	}
 }
 
+func NodePath(n *html.Node) string {
+	for n != nil && n.Type != html.ElementNode {
+		n = n.Parent
+	}
+
+	var parts []string
+	for ; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		if n.Parent == nil || n.Parent.Type != html.ElementNode {
+			parts = append(parts, n.Data)
+			continue
+		}
+		parts = append(parts, n.Data+":nth-child("+strconv.Itoa(elementIndex(n))+")")
+	}
+
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, " > ")
+}
+
+func elementIndex(n *html.Node) int {
+	i := 1
+	for c := n.PrevSibling; c != nil; c = c.PrevSibling {
+		if c.Type == html.ElementNode {
+			i++
+		}
+	}
+	return i
+}
+
 func Render(w io.Writer, s *Selection) error {
 	if s.Length() == 0 {
 		return nil
@@ -0,0 +1,411 @@
+package launder
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type VisibilityHeuristic uint
+
+const (
+	HeuristicHiddenAttr VisibilityHeuristic = 1 << iota
+	HeuristicAriaHidden
+	HeuristicStyle
+	HeuristicNonRendered
+	HeuristicHiddenClass
+	HeuristicOffScreen
+	HeuristicZeroSize
+
+	AllHeuristics = HeuristicHiddenAttr | HeuristicAriaHidden | HeuristicStyle |
+		HeuristicNonRendered | HeuristicHiddenClass | HeuristicOffScreen | HeuristicZeroSize
+)
+
+const offScreenThreshold = -500
+
+var DefaultHiddenClasses = []string{
+	"sr-only",
+	"visually-hidden",
+	"visuallyhidden",
+	"screen-reader-text",
+	"screen-reader-only",
+	"a11y-hidden",
+	"hidden",
+	"d-none",
+	"is-hidden",
+}
+
+var nonRenderedElements = map[string]bool{
+	"template": true,
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+}
+
+type VisibilityOptions struct {
+	Heuristics    VisibilityHeuristic
+	HiddenClasses []string
+	Cascade       *Cascade
+}
+
+func DefaultVisibilityOptions() *VisibilityOptions {
+	return &VisibilityOptions{
+		Heuristics:    AllHeuristics,
+		HiddenClasses: DefaultHiddenClasses,
+	}
+}
+
+func (s *Selection) IsVisible() bool {
+	return s.IsVisibleWith(nil)
+}
+
+func (s *Selection) IsVisibleWith(opts *VisibilityOptions) bool {
+	vc := newVisibilityChecker(opts)
+	for _, n := range s.Nodes {
+		if !vc.isHidden(n) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Selection) Visible() *Selection {
+	return s.VisibleWith(nil)
+}
+
+func (s *Selection) VisibleWith(opts *VisibilityOptions) *Selection {
+	vc := newVisibilityChecker(opts)
+	return pushStack(s, grep(s, func(_ int, sel *Selection) bool {
+		return !vc.isHidden(sel.Get(0))
+	}))
+}
+
+func (s *Selection) Hidden() *Selection {
+	return s.HiddenWith(nil)
+}
+
+func (s *Selection) HiddenWith(opts *VisibilityOptions) *Selection {
+	vc := newVisibilityChecker(opts)
+	return pushStack(s, grep(s, func(_ int, sel *Selection) bool {
+		return vc.isHidden(sel.Get(0))
+	}))
+}
+
+func (s *Selection) StripHidden() *Selection {
+	return s.StripHiddenWith(nil)
+}
+
+func (s *Selection) StripHiddenWith(opts *VisibilityOptions) *Selection {
+	s, _ = s.writable()
+	vc := newVisibilityChecker(opts)
+
+	var removed []*html.Node
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type == html.TextNode && n.Type == html.ElementNode && vc.visibilityHidden(n):
+				removed = append(removed, c)
+			case c.Type != html.ElementNode:
+			case vc.isHiddenSelf(c):
+				removed = append(removed, c)
+			case vc.visibilityHidden(c) && !vc.hasVisibleDescendant(c):
+				removed = append(removed, c)
+			default:
+				f(c)
+			}
+		}
+	}
+	for _, n := range s.Nodes {
+		f(n)
+	}
+
+	for _, n := range removed {
+		n.Parent.RemoveChild(n)
+	}
+
+	return pushStack(s, removed)
+}
+
+type visibilityChecker struct {
+	opts    *VisibilityOptions
+	classes map[string]bool
+	boxes   map[*html.Node]bool
+	styles  map[*html.Node]Style
+}
+
+func newVisibilityChecker(opts *VisibilityOptions) *visibilityChecker {
+	if opts == nil {
+		opts = DefaultVisibilityOptions()
+	}
+	vc := &visibilityChecker{
+		opts:    opts,
+		classes: make(map[string]bool, len(opts.HiddenClasses)),
+		boxes:   make(map[*html.Node]bool),
+		styles:  make(map[*html.Node]Style),
+	}
+	for _, c := range opts.HiddenClasses {
+		vc.classes[c] = true
+	}
+	return vc
+}
+
+func (vc *visibilityChecker) has(h VisibilityHeuristic) bool {
+	return vc.opts.Heuristics&h != 0
+}
+
+func (vc *visibilityChecker) isHidden(n *html.Node) bool {
+	for n != nil && n.Type != html.ElementNode {
+		n = n.Parent
+	}
+	if n == nil {
+		return false
+	}
+	return vc.boxHidden(n) || vc.visibilityHidden(n)
+}
+
+func (vc *visibilityChecker) boxHidden(n *html.Node) bool {
+	if n == nil || n.Type != html.ElementNode {
+		return false
+	}
+	if h, ok := vc.boxes[n]; ok {
+		return h
+	}
+
+	h := vc.isHiddenSelf(n) || vc.boxHidden(n.Parent)
+	vc.boxes[n] = h
+	return h
+}
+
+func (vc *visibilityChecker) visibilityHidden(n *html.Node) bool {
+	if !vc.has(HeuristicStyle) {
+		return false
+	}
+	vis := strings.ToLower(vc.style(n).Get("visibility"))
+	return vis == "hidden" || vis == "collapse"
+}
+
+func (vc *visibilityChecker) hasVisibleDescendant(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || vc.isHiddenSelf(c) {
+			continue
+		}
+		if !vc.visibilityHidden(c) || vc.hasVisibleDescendant(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func (vc *visibilityChecker) isHiddenSelf(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+
+	if vc.has(HeuristicNonRendered) && nonRenderedElements[n.Data] {
+		return true
+	}
+	if vc.has(HeuristicHiddenAttr) {
+		if _, ok := getAttributeValue("hidden", n); ok {
+			return true
+		}
+		if n.Data == "input" {
+			if t, _ := getAttributeValue("type", n); strings.EqualFold(t, "hidden") {
+				return true
+			}
+		}
+	}
+	if vc.has(HeuristicAriaHidden) {
+		if v, _ := getAttributeValue("aria-hidden", n); strings.EqualFold(strings.TrimSpace(v), "true") {
+			return true
+		}
+	}
+	if vc.has(HeuristicHiddenClass) && len(vc.classes) > 0 {
+		if v, ok := getAttributeValue("class", n); ok {
+			for _, c := range strings.Fields(v) {
+				if vc.classes[c] {
+					return true
+				}
+			}
+		}
+	}
+
+	if !vc.has(HeuristicStyle | HeuristicOffScreen | HeuristicZeroSize) {
+		return false
+	}
+
+	st := vc.style(n)
+	if vc.has(HeuristicStyle) && styleHidden(st) {
+		return true
+	}
+	if vc.has(HeuristicOffScreen) && styleOffScreen(st) {
+		return true
+	}
+	if vc.has(HeuristicZeroSize) && (styleZeroSize(st) || attrZeroSize(n)) {
+		return true
+	}
+
+	return false
+}
+
+func (vc *visibilityChecker) style(n *html.Node) Style {
+	if st, ok := vc.styles[n]; ok {
+		return st
+	}
+	if vc.opts.Cascade != nil {
+		st := vc.opts.Cascade.ComputedStyle(n)
+		vc.styles[n] = st
+		return st
+	}
+
+	st := make(Style)
+	if v, ok := getAttributeValue("style", n); ok {
+		for _, d := range ParseDeclarations(v) {
+			st[d.Property] = d.Value
+		}
+	}
+	if _, ok := st["visibility"]; !ok {
+		for p := n.Parent; p != nil && p.Type == html.ElementNode; p = p.Parent {
+			if v, ok := getAttributeValue("style", p); ok {
+				if vis, found := lastDeclaration(ParseDeclarations(v), "visibility"); found {
+					st["visibility"] = vis
+					break
+				}
+			}
+		}
+	}
+	vc.styles[n] = st
+	return st
+}
+
+func lastDeclaration(decls []Declaration, property string) (string, bool) {
+	for i := len(decls) - 1; i >= 0; i-- {
+		if decls[i].Property == property {
+			return decls[i].Value, true
+		}
+	}
+	return "", false
+}
+
+func styleHidden(st Style) bool {
+	if st.Is("display", "none") {
+		return true
+	}
+	if op := st.Get("opacity"); op != "" {
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(op, "%"), 64); err == nil && v <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func styleOffScreen(st Style) bool {
+	pos := strings.ToLower(st.Get("position"))
+	if pos == "absolute" || pos == "fixed" {
+		for _, prop := range []string{"left", "top", "right", "bottom"} {
+			if v, ok := parseCSSLength(st.Get(prop)); ok && v <= offScreenThreshold {
+				return true
+			}
+		}
+	}
+	if v, ok := parseCSSLength(st.Get("text-indent")); ok && v <= offScreenThreshold {
+		return true
+	}
+
+	clip := strings.ToLower(strings.Join(strings.Fields(st.Get("clip")), ""))
+	if strings.HasPrefix(clip, "rect(") {
+		args := strings.FieldsFunc(strings.TrimSuffix(strings.TrimPrefix(clip, "rect("), ")"), func(r rune) bool {
+			return r == ','
+		})
+		if len(args) == 4 {
+			var edges [4]float64
+			lengths := true
+			for i, a := range args {
+				if edges[i], lengths = parseCSSLength(a); !lengths {
+					break
+				}
+			}
+			if lengths && (edges[2]-edges[0] <= 1 || edges[1]-edges[3] <= 1) {
+				return true
+			}
+		}
+	}
+
+	cp := strings.ToLower(strings.Join(strings.Fields(st.Get("clip-path")), ""))
+	if cp == "inset(50%)" || cp == "inset(100%)" || cp == "circle(0)" || cp == "polygon(0000)" {
+		return true
+	}
+
+	return false
+}
+
+func styleZeroSize(st Style) bool {
+	w, wok := parseCSSLength(st.Get("width"))
+	h, hok := parseCSSLength(st.Get("height"))
+	overflow := strings.ToLower(st.Get("overflow"))
+	clipped := overflow == "hidden" || overflow == "clip"
+
+	switch {
+	case wok && hok && w <= 1 && h <= 1 && clipped:
+		return true
+	case wok && w == 0 && clipped, hok && h == 0 && clipped:
+		return true
+	case wok && hok && w == 0 && h == 0:
+		return true
+	}
+
+	if fs, ok := parseCSSLength(st.Get("font-size")); ok && fs == 0 {
+		return true
+	}
+	if sc := strings.ToLower(strings.Join(strings.Fields(st.Get("transform")), "")); sc == "scale(0)" {
+		return true
+	}
+	return false
+}
+
+func attrZeroSize(n *html.Node) bool {
+	w, wok := getAttributeValue("width", n)
+	h, hok := getAttributeValue("height", n)
+	if !wok || !hok {
+		return false
+	}
+	wv, werr := strconv.Atoi(strings.TrimSpace(w))
+	hv, herr := strconv.Atoi(strings.TrimSpace(h))
+	return werr == nil && herr == nil && wv <= 1 && hv <= 1
+}
+
+func parseCSSLength(v string) (float64, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" {
+		return 0, false
+	}
+
+	end := 0
+	for end < len(v) && (v[end] == '-' || v[end] == '+' || v[end] == '.' || '0' <= v[end] && v[end] <= '9') {
+		end++
+	}
+	num, err := strconv.ParseFloat(v[:end], 64)
+	if err != nil {
+		return 0, false
+	}
+
+	switch unit := v[end:]; unit {
+	case "", "px":
+		return num, true
+	case "em", "rem":
+		return num * 16, true
+	case "pt":
+		return num * 4 / 3, true
+	case "pc":
+		return num * 16, true
+	case "in":
+		return num * 96, true
+	case "cm":
+		return num * 96 / 2.54, true
+	case "mm":
+		return num * 96 / 25.4, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,82 @@
+package launder
+
+import (
+	"strings"
+	"testing"
+)
+
+const visibilityPage = `<html><head><style>.gone { display: none }</style></head><body>
+<p id="a">visible</p>
+<p id="b" hidden>hidden attr</p>
+<p id="c" aria-hidden="true">aria</p>
+<p id="d" style="display:none">inline</p>
+<p id="e" class="sr-only">sr</p>
+<p id="f" style="position:absolute; left:-9999px">offscreen</p>
+<p id="g" style="width:0; height:0; overflow:hidden">zero</p>
+<p id="h" class="gone">sheet</p>
+<div style="visibility:hidden"><span id="i">inherited</span><span id="j" style="visibility:visible">shown</span></div>
+<template><p id="k">tpl</p></template>
+</body></html>`
+
+func TestIsVisible(t *testing.T) {
+	doc := loadString(t, visibilityPage)
+
+	for _, id := range []string{"a", "h", "j"} {
+		if !doc.Find("#" + id).IsVisible() {
+			t.Errorf("expected #%s to be visible", id)
+		}
+	}
+	for _, id := range []string{"b", "c", "d", "e", "f", "g", "i"} {
+		if doc.Find("#" + id).IsVisible() {
+			t.Errorf("expected #%s to be hidden", id)
+		}
+	}
+
+	opts := DefaultVisibilityOptions()
+	opts.Cascade = NewCascade(doc)
+	if doc.Find("#h").IsVisibleWith(opts) {
+		t.Error("expected #h to be hidden by the stylesheet")
+	}
+
+	opts = &VisibilityOptions{Heuristics: HeuristicHiddenAttr}
+	if !doc.Find("#c").IsVisibleWith(opts) {
+		t.Error("expected #c to be visible when aria-hidden is ignored")
+	}
+}
+
+func TestStripHidden(t *testing.T) {
+	doc := loadString(t, visibilityPage)
+
+	doc.Find("body").StripHidden()
+	text := strings.Join(strings.Fields(doc.Find("body").Text()), " ")
+	if text != "visible sheet shown" {
+		t.Errorf("unexpected text after stripping hidden content: %q", text)
+	}
+}
+
+func TestStyleOffScreen(t *testing.T) {
+	cases := []struct {
+		style string
+		want  bool
+	}{
+		{"position:absolute; left:-9999px", true},
+		{"text-indent:-200em", true},
+		{"position:absolute; clip:rect(0, 0, 0, 0)", true},
+		{"position:absolute; clip:rect(1px, 1px, 1px, 1px)", true},
+		{"position:absolute; clip:rect(auto, auto, auto, auto)", false},
+		{"position:absolute; clip:rect(0, auto, 10px, 0)", false},
+		{"position:absolute; clip:rect(0, 100px, 50px, 0)", false},
+		{"position:absolute; left:-50%", false},
+		{"position:absolute; top:-200vh", false},
+		{"text-indent:-100vw", false},
+	}
+	for _, c := range cases {
+		st := make(Style)
+		for _, d := range ParseDeclarations(c.style) {
+			st[d.Property] = d.Value
+		}
+		if got := styleOffScreen(st); got != c.want {
+			t.Errorf("%q: expected off-screen=%v, got %v", c.style, c.want, got)
+		}
+	}
+}
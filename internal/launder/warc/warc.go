@@ -0,0 +1,217 @@
+package warc
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geistblitz/boringformat/internal/launder"
+)
+
+var ErrMalformed = errors.New("warc: malformed record")
+
+var DefaultMIMETypes = []string{
+	"text/html",
+	"application/xhtml+xml",
+}
+
+type Options struct {
+	Types     []string
+	MIMETypes []string
+}
+
+func DefaultOptions() *Options {
+	return &Options{
+		Types:     []string{"response"},
+		MIMETypes: DefaultMIMETypes,
+	}
+}
+
+type Record struct {
+	Header        textproto.MIMEHeader
+	Type          string
+	ID            string
+	TargetURI     string
+	Date          time.Time
+	ContentType   string
+	ContentLength int64
+
+	Response *http.Response
+
+	block io.Reader
+}
+
+func (rec *Record) Body() io.Reader {
+	if rec.Response != nil {
+		return rec.Response.Body
+	}
+	return rec.block
+}
+
+func (rec *Record) MIMEType() string {
+	ct := rec.ContentType
+	if rec.Response != nil {
+		ct = rec.Response.Header.Get("Content-Type")
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+func (rec *Record) Document() (*launder.Document, error) {
+	body := rec.Body()
+	ct := rec.ContentType
+	if rec.Response != nil {
+		ct = rec.Response.Header.Get("Content-Type")
+		if strings.EqualFold(rec.Response.Header.Get("Content-Encoding"), "gzip") {
+			zr, err := gzip.NewReader(body)
+			if err != nil {
+				return nil, err
+			}
+			defer zr.Close()
+			body = zr
+		}
+	}
+
+	doc, err := launder.NewDocumentFromReaderWithContentType(body, ct)
+	if err != nil {
+		return nil, err
+	}
+	if rec.TargetURI != "" {
+		if u, err := url.Parse(rec.TargetURI); err == nil {
+			doc.Url = u
+		}
+	}
+	return doc, nil
+}
+
+type Reader struct {
+	opts  Options
+	br    *bufio.Reader
+	block *io.LimitedReader
+}
+
+func NewReader(r io.Reader, opts *Options) (*Reader, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return &Reader{opts: *opts, br: bufio.NewReader(zr)}, nil
+	}
+	return &Reader{opts: *opts, br: br}, nil
+}
+
+func (r *Reader) Next() (*Record, error) {
+	for {
+		rec, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		if r.accept(rec) {
+			return rec, nil
+		}
+	}
+}
+
+func (r *Reader) accept(rec *Record) bool {
+	if len(r.opts.Types) > 0 && !containsFold(r.opts.Types, rec.Type) {
+		return false
+	}
+	if len(r.opts.MIMETypes) > 0 && !containsFold(r.opts.MIMETypes, rec.MIMEType()) {
+		return false
+	}
+	return true
+}
+
+func (r *Reader) next() (*Record, error) {
+	if r.block != nil {
+		if _, err := io.Copy(io.Discard, r.block); err != nil {
+			return nil, err
+		}
+		r.block = nil
+	}
+
+	var line string
+	for {
+		b, err := r.br.ReadString('\n')
+		if err == io.EOF && strings.TrimSpace(b) == "" {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line = strings.TrimSpace(b); line != "" {
+			break
+		}
+	}
+	if !strings.HasPrefix(line, "WARC/") {
+		return nil, fmt.Errorf("%w: unexpected version line %q", ErrMalformed, line)
+	}
+
+	header, err := textproto.NewReader(r.br).ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("%w: invalid Content-Length %q", ErrMalformed, header.Get("Content-Length"))
+	}
+
+	r.block = &io.LimitedReader{R: r.br, N: length}
+	rec := &Record{
+		Header:        header,
+		Type:          strings.ToLower(header.Get("WARC-Type")),
+		ID:            header.Get("WARC-Record-ID"),
+		TargetURI:     strings.Trim(header.Get("WARC-Target-URI"), "<>"),
+		ContentType:   header.Get("Content-Type"),
+		ContentLength: length,
+		block:         r.block,
+	}
+	if d := header.Get("WARC-Date"); d != "" {
+		rec.Date, _ = time.Parse(time.RFC3339, d)
+	}
+
+	if rec.Type == "response" && isHTTPResponse(rec.ContentType) {
+		res, err := http.ReadResponse(bufio.NewReader(r.block), nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, rec.ID, err)
+		}
+		rec.Response = res
+	}
+	return rec, nil
+}
+
+func isHTTPResponse(ct string) bool {
+	mt, params, err := mime.ParseMediaType(ct)
+	if err != nil || mt != "application/http" {
+		return false
+	}
+	msgtype, ok := params["msgtype"]
+	return !ok || msgtype == "response"
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func record(typ, uri, ct, block string) string {
+	return fmt.Sprintf("WARC/1.0\r\nWARC-Type: %s\r\nWARC-Record-ID: <urn:uuid:%s>\r\nWARC-Date: 2024-01-02T03:04:05Z\r\nWARC-Target-URI: %s\r\nContent-Type: %s\r\nContent-Length: %d\r\n\r\n%s\r\n\r\n",
+		typ, uri, uri, ct, len(block), block)
+}
+
+func httpResponse(ct, body string) string {
+	return fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: %s\r\nContent-Length: %d\r\n\r\n%s", ct, len(body), body)
+}
+
+func testArchive() []string {
+	return []string{
+		record("warcinfo", "", "application/warc-fields", "software: test\r\n"),
+		record("request", "http://example.com/a", "application/http; msgtype=request", "GET /a HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+		record("response", "http://example.com/a", "application/http; msgtype=response", httpResponse("text/html", `<html><body><a href="b">first</a></body></html>`)),
+		record("response", "http://example.com/logo.png", "application/http; msgtype=response", httpResponse("image/png", "\x89PNG")),
+		record("response", "http://example.com/b", "application/http; msgtype=response", httpResponse("text/html; charset=utf-8", `<html><body><p>second</p></body></html>`)),
+	}
+}
+
+func TestReader(t *testing.T) {
+	var plain, compressed bytes.Buffer
+	for _, rec := range testArchive() {
+		plain.WriteString(rec)
+		zw := gzip.NewWriter(&compressed)
+		zw.Write([]byte(rec))
+		zw.Close()
+	}
+
+	for name, data := range map[string][]byte{"plain": plain.Bytes(), "gzip": compressed.Bytes()} {
+		r, err := NewReader(bytes.NewReader(data), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var uris, texts []string
+		for {
+			rec, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if rec.Date.Year() != 2024 || rec.ID == "" {
+				t.Errorf("%s: missing record metadata: %+v", name, rec)
+			}
+			doc, err := rec.Document()
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			uris = append(uris, doc.Url.String())
+			texts = append(texts, doc.Find("body").Text())
+		}
+
+		if fmt.Sprint(uris) != "[http://example.com/a http://example.com/b]" {
+			t.Errorf("%s: unexpected records %v", name, uris)
+		}
+		if fmt.Sprint(texts) != "[first second]" {
+			t.Errorf("%s: unexpected texts %v", name, texts)
+		}
+	}
+}
+
+func TestReaderAllRecords(t *testing.T) {
+	var buf bytes.Buffer
+	for _, rec := range testArchive() {
+		buf.WriteString(rec)
+	}
+
+	r, _ := NewReader(&buf, &Options{})
+	var types []string
+	for {
+		rec, err := r.Next()
+		if err != nil {
+			break
+		}
+		types = append(types, rec.Type+":"+rec.MIMEType())
+	}
+	want := "[warcinfo:application/warc-fields request:application/http response:text/html response:image/png response:text/html]"
+	if fmt.Sprint(types) != want {
+		t.Errorf("expected %s, got %v", want, types)
+	}
+}